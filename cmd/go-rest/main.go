@@ -22,6 +22,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path"
@@ -34,12 +35,25 @@ import (
 
 	"github.com/cstaaben/go-rest/internal/config"
 	"github.com/cstaaben/go-rest/internal/model"
+	"github.com/cstaaben/go-rest/internal/secrets"
 )
 
 func init() {
 	flag.StringP("config", "c", config.DefaultPath, "Path to the configuration file")
+	flag.String("set-secret", "", "Store the value read from stdin as the named secret in the vault, then exit")
+	flag.String("delete-secret", "", "Delete the named secret from the vault, then exit")
+	flag.Uint64("seed", 0, "Seed for random values generated in requests, e.g. {{$uuid}}; random if not set")
+	flag.String(
+		"now",
+		"",
+		"Time used for timestamps generated in requests, e.g. {{$timestamp}}, in RFC 3339 format; "+
+			seededTime+" if --seed is set, the current time otherwise",
+	)
 }
 
+// seededTime is what the clock is pinned to when a seed is given without a time, so timestamps are reproducible too.
+const seededTime = "2000-01-01T00:00:00Z"
+
 func main() {
 	flag.Parse()
 
@@ -59,9 +73,44 @@ func main() {
 	}
 	defer closeFn()
 
-	slog.Debug("Starting client", slog.String("colorscheme", config.ColorScheme()))
+	vault, err := secrets.Unlock(config.Vault().Path, config.Vault().KeyFile)
+	if err != nil && !errors.Is(err, secrets.ErrLocked) {
+		fmt.Println("secrets:", err)
+		os.Exit(1)
+	}
+
+	if name, _ := flag.CommandLine.GetString("set-secret"); name != "" {
+		exitOnError(setSecret(vault, err, name))
+		return
+	}
+
+	if name, _ := flag.CommandLine.GetString("delete-secret"); name != "" {
+		exitOnError(deleteSecret(vault, err, name))
+		return
+	}
+
+	slog.Debug("Starting client", slog.String("colorscheme", config.ColorScheme()), slog.Bool("vault_unlocked", vault != nil))
+
+	var opts []model.Option
+	now, _ := flag.CommandLine.GetString("now")
+	if flag.CommandLine.Changed("seed") {
+		seed, _ := flag.CommandLine.GetUint64("seed")
+		opts = append(opts, model.WithSeed(seed))
 
-	p := tea.NewProgram(model.New(), tea.WithAltScreen(), tea.WithContext(ctx))
+		if now == "" {
+			now = seededTime
+		}
+	}
+	if now != "" {
+		pinned, err := time.Parse(time.RFC3339, now)
+		if err != nil {
+			fmt.Println("now:", err)
+			os.Exit(1)
+		}
+		opts = append(opts, model.WithClock(func() time.Time { return pinned }))
+	}
+
+	p := tea.NewProgram(model.New(ctx, vault, opts...), tea.WithAltScreen(), tea.WithContext(ctx))
 	if _, err := p.Run(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
@@ -91,8 +140,9 @@ func setupLogger(cfg config.Log) (func(), error) {
 	}
 
 	opts := &slog.HandlerOptions{
-		Level:     lvl.Level(),
-		AddSource: true,
+		Level:       lvl.Level(),
+		AddSource:   true,
+		ReplaceAttr: maskSecrets,
 	}
 
 	var handler slog.Handler
@@ -108,6 +158,57 @@ func setupLogger(cfg config.Log) (func(), error) {
 	return closeFn, nil
 }
 
+// maskSecrets hides the value of any secret in log attributes.
+func maskSecrets(_ []string, a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(secrets.Mask(a.Value.String()))
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			if masked := secrets.Mask(v.Error()); masked != v.Error() {
+				a.Value = slog.StringValue(masked)
+			}
+		case fmt.Stringer:
+			if masked := secrets.Mask(v.String()); masked != v.String() {
+				a.Value = slog.StringValue(masked)
+			}
+		}
+	}
+
+	return a
+}
+
+func setSecret(vault *secrets.Vault, unlockErr error, name string) error {
+	if vault == nil {
+		return unlockErr
+	}
+
+	value, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("reading secret: %w", err)
+	}
+
+	vault.Set(name, strings.TrimRight(string(value), "\r\n"))
+	return vault.Save()
+}
+
+func deleteSecret(vault *secrets.Vault, unlockErr error, name string) error {
+	if vault == nil {
+		return unlockErr
+	}
+
+	vault.Delete(name)
+	return vault.Save()
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Println("secrets:", err)
+		os.Exit(1)
+	}
+}
+
 func openLogFile(filepath string) (*os.File, error) {
 	var (
 		file *os.File
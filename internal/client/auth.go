@@ -0,0 +1,271 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cstaaben/go-rest/internal/request"
+	"github.com/cstaaben/go-rest/internal/secrets"
+)
+
+// applyAuth adds the credentials of auth to req. Digest authentication needs a challenge from the server first, and
+// OAuth 2.0 a token from the authorization server, so they are handled when the request is sent.
+func applyAuth(req *http.Request, body []byte, auth *request.Auth, now time.Time) error {
+	switch {
+	case auth == nil, auth.Type == request.AuthNone, auth.Type == request.AuthDigest, auth.Type == request.AuthOAuth2:
+	case auth.Type == request.AuthBasic:
+		req.SetBasicAuth(auth.Username, auth.Password)
+	case auth.Type == request.AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+	case auth.Type == request.AuthAPIKey:
+		return applyAPIKey(req, auth)
+	case auth.Type == request.AuthAWS:
+		return SignV4(req, body, auth, now)
+	default:
+		return fmt.Errorf("unsupported auth type %q", auth.Type)
+	}
+
+	return nil
+}
+
+func applyAPIKey(req *http.Request, auth *request.Auth) error {
+	if auth.Key == "" {
+		return errors.New("API key auth is missing the name of the key")
+	}
+
+	switch auth.In {
+	case request.APIKeyInHeader, "":
+		req.Header.Set(auth.Key, auth.Value)
+	case request.APIKeyInQuery:
+		param := url.QueryEscape(auth.Key) + "=" + url.QueryEscape(auth.Value)
+		if req.URL.RawQuery == "" {
+			req.URL.RawQuery = param
+		} else {
+			req.URL.RawQuery += "&" + param
+		}
+	default:
+		return fmt.Errorf("unsupported API key location %q", auth.In)
+	}
+
+	return nil
+}
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+)
+
+// SignV4 signs req with AWS Signature Version 4, using the credentials, region and service of auth and the time now.
+// The body must be the body req sends, and every header of req is signed, so no headers may be added afterward.
+func SignV4(req *http.Request, body []byte, auth *request.Auth, now time.Time) error {
+	var missing []string
+	for name, value := range map[string]string{
+		"access key": auth.AccessKey,
+		"secret key": auth.SecretKey,
+		"region":     auth.Region,
+		"service":    auth.Service,
+	} {
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return fmt.Errorf("AWS signature is missing the %s", strings.Join(missing, ", "))
+	}
+
+	now = now.UTC()
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", now.Format(sigV4TimeFormat))
+	if auth.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", auth.SessionToken)
+	}
+
+	payloadHash := sha256Hex(body)
+	if auth.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	headers, signedHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL, auth.Service),
+		canonicalQuery(req.URL),
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, auth.Region, auth.Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		now.Format(sigV4TimeFormat),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := []byte("AWS4" + auth.SecretKey)
+	for _, part := range []string{date, auth.Region, auth.Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, auth.AccessKey, scope, signedHeaders, signature,
+	))
+
+	return nil
+}
+
+// canonicalPath encodes every segment of the path of u. Services other than S3 expect the already escaped path to be
+// encoded a second time.
+func canonicalPath(u *url.URL, service string) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	if service == "s3" {
+		return path
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery lists the query parameters of u sorted by name and then value.
+func canonicalQuery(u *url.URL) string {
+	var params []string
+	for name, values := range u.Query() {
+		for _, value := range values {
+			params = append(params, uriEncode(name)+"="+uriEncode(value))
+		}
+	}
+	slices.Sort(params)
+
+	return strings.Join(params, "&")
+}
+
+// canonicalHeaders returns the headers of req, including its host, in the form they are signed in, followed by the
+// list of their names.
+func canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	values := map[string]string{"host": host}
+	for name, vals := range req.Header {
+		name = strings.ToLower(name)
+		if name == "authorization" {
+			continue
+		}
+
+		trimmed := make([]string, 0, len(vals))
+		for _, v := range vals {
+			trimmed = append(trimmed, strings.Join(strings.Fields(v), " "))
+		}
+		values[name] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + values[name] + "\n")
+	}
+
+	return b.String(), strings.Join(names, ";")
+}
+
+// uriEncode percent-encodes every byte of s except the unreserved characters of RFC 3986.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+// credentialHeaders are the headers whose values are redacted when a request is dumped, since they may hold encoded
+// credentials that masking the secrets themselves doesn't catch, e.g. the base64 of a basic auth password.
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization"}
+
+// redactCredentials replaces the credentials in the headers of a dumped request with secrets.Masked, keeping only the
+// authentication scheme.
+func redactCredentials(raw []byte) []byte {
+	head, body, hasBody := strings.Cut(string(raw), "\r\n\r\n")
+
+	lines := strings.Split(head, "\r\n")
+	for i, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || !slices.ContainsFunc(credentialHeaders, func(h string) bool { return strings.EqualFold(h, name) }) {
+			continue
+		}
+
+		redacted := secrets.Masked
+		if scheme, _, found := strings.Cut(strings.TrimSpace(value), " "); found {
+			redacted = scheme + " " + secrets.Masked
+		}
+		lines[i] = name + ": " + redacted
+	}
+
+	result := strings.Join(lines, "\r\n")
+	if hasBody {
+		result += "\r\n\r\n" + body
+	}
+
+	return []byte(result)
+}
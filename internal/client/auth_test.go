@@ -0,0 +1,198 @@
+package client_test
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/client"
+	"github.com/cstaaben/go-rest/internal/oauth"
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+func TestSignV4(t *testing.T) {
+	// the ListUsers example from the AWS Signature Version 4 documentation
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	auth := &request.Auth{
+		Type:      request.AuthAWS,
+		AccessKey: "AKIDEXAMPLE",
+		SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:    "us-east-1",
+		Service:   "iam",
+	}
+
+	require.NoError(t, client.SignV4(req, nil, auth, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)))
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(
+		t,
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, "+
+			"SignedHeaders=content-type;host;x-amz-date, "+
+			"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		req.Header.Get("Authorization"),
+	)
+
+	auth.Region = ""
+	assert.ErrorContains(t, client.SignV4(req, nil, auth, time.Now()), "missing the region")
+}
+
+func TestNewHTTPRequest_APIKey(t *testing.T) {
+	testCases := []struct {
+		name         string
+		auth         *request.Auth
+		expectedURL  string
+		expectedHdr  string
+		expectingErr bool
+	}{
+		{
+			name:        "Header",
+			auth:        &request.Auth{Type: request.AuthAPIKey, Key: "X-Api-Key", Value: "secret"},
+			expectedURL: "https://example.com/?a=1",
+			expectedHdr: "secret",
+		},
+		{
+			name:        "Query",
+			auth:        &request.Auth{Type: request.AuthAPIKey, Key: "api key", Value: "s&cret", In: request.APIKeyInQuery},
+			expectedURL: "https://example.com/?a=1&api+key=s%26cret",
+		},
+		{
+			name:         "Missing key",
+			auth:         &request.Auth{Type: request.AuthAPIKey, Value: "secret"},
+			expectingErr: true,
+		},
+		{
+			name:         "Unknown location",
+			auth:         &request.Auth{Type: request.AuthAPIKey, Key: "key", In: "cookie"},
+			expectingErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				d := &request.Data{URL: "https://example.com/?a=1", Auth: tc.auth}
+
+				req, err := client.NewHTTPRequest(context.Background(), d)
+				if tc.expectingErr {
+					assert.Error(t, err)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedURL, req.URL.String())
+				assert.Equal(t, tc.expectedHdr, req.Header.Get("X-Api-Key"))
+			},
+		)
+	}
+}
+
+func TestClient_DoDigest(t *testing.T) {
+	const (
+		realm = "test@example.com"
+		nonce = "dcd98b7102dd2f0e8b11d0f600bfb0c093"
+	)
+
+	h := func(parts ...string) string {
+		sum := md5.Sum([]byte(strings.Join(parts, ":")))
+		return hex.EncodeToString(sum[:])
+	}
+
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+
+		params := make(map[string]string)
+		scheme, rest, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		for _, param := range strings.Split(rest, ", ") {
+			name, value, _ := strings.Cut(param, "=")
+			params[name] = strings.Trim(value, `"`)
+		}
+
+		// the expected response is computed with the password the client should have used
+		expected := h(h(params["username"], realm, "Circle Of Life"), nonce, params["nc"], params["cnonce"], params["qop"], h(r.Method, params["uri"]))
+		if scheme != "Digest" || params["response"] != expected || params["opaque"] != "opaque" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest realm=%q, qop="auth,auth-int", nonce=%q, opaque="opaque"`, realm, nonce))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	testCases := []struct {
+		name         string
+		password     string
+		expectedCode int
+	}{
+		{
+			name:         "Valid credentials",
+			password:     "Circle Of Life",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Invalid credentials",
+			password:     "wrong",
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				attempts = 0
+				d := &request.Data{
+					URL:  srv.URL + "/dir/index.html?a=1",
+					Auth: &request.Auth{Type: request.AuthDigest, Username: "Mufasa", Password: tc.password},
+				}
+
+				resp, err := client.New().Do(context.Background(), &request.Request{Data: d})
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedCode, resp.StatusCode)
+				// the challenge is only answered once
+				assert.Equal(t, 2, attempts)
+			},
+		)
+	}
+}
+
+// tokenSource issues the same token for every request.
+type tokenSource struct {
+	token *oauth.Token
+}
+
+func (s tokenSource) Token(context.Context, *request.Auth) (*oauth.Token, error) {
+	return s.token, nil
+}
+
+func TestClient_DoOAuth2(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Authorization", r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	r := &request.Request{Data: &request.Data{URL: srv.URL, Auth: &request.Auth{Type: request.AuthOAuth2}}}
+
+	_, err := client.New().Do(context.Background(), r)
+	assert.ErrorContains(t, err, "OAuth 2.0 is not available")
+
+	tokens := tokenSource{token: &oauth.Token{AccessToken: "abc", TokenType: "bearer"}}
+	resp, err := client.New(client.WithTokens(tokens)).Do(context.Background(), r)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer abc", http.Header(resp.Headers).Get("X-Authorization"))
+}
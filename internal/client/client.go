@@ -1,7 +1,381 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Package client executes requests over HTTP.
 package client
 
-import "net/http"
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptrace"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cstaaben/go-rest/internal/oauth"
+	"github.com/cstaaben/go-rest/internal/request"
+	"github.com/cstaaben/go-rest/internal/transport"
+)
+
+// ErrNoData is returned when a request without any request data is sent.
+var ErrNoData = errors.New("request has no data")
+
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying HTTP client used to send requests.
+func WithHTTPClient(c *http.Client) Option {
+	return func(client *Client) {
+		client.Client = c
+	}
+}
+
+// WithTokens gets the access tokens of requests using OAuth 2.0 from tokens.
+func WithTokens(tokens TokenSource) Option {
+	return func(client *Client) {
+		client.Tokens = tokens
+	}
+}
+
+// WithCookies sends the cookies in jar with requests, and stores the cookies set by their responses in it.
+func WithCookies(jar http.CookieJar) Option {
+	return func(client *Client) {
+		client.Jar = jar
+	}
+}
 
+// TokenSource provides the access tokens of requests using OAuth 2.0.
+type TokenSource interface {
+	Token(ctx context.Context, auth *request.Auth) (*oauth.Token, error)
+}
+
+// Client sends requests and collects their responses.
 type Client struct {
+	// mu guards Client, which is replaced when the client is configured while requests are being sent.
+	mu     sync.Mutex
 	Client *http.Client
+	// protocols are the round trippers derived from the transport of Client to send requests with a specific protocol.
+	protocols   map[string]http.RoundTripper
+	protocolsOf http.RoundTripper
+	// Tokens provides OAuth 2.0 access tokens. Requests using OAuth 2.0 can't be sent without it.
+	Tokens TokenSource
+	// Jar keeps the cookies of responses for later requests. Requests can opt out of it with Data.NoCookies.
+	Jar http.CookieJar
+}
+
+// New creates a new Client and applies the provided options.
+func New(opts ...Option) *Client {
+	c := &Client{Client: new(http.Client)}
+
+	for _, optFunc := range opts {
+		optFunc(c)
+	}
+
+	return c
+}
+
+// Configure sends requests over connections with the given settings from now on. Requests already being sent are
+// unaffected.
+func (c *Client) Configure(settings transport.Settings) error {
+	t, err := settings.Transport()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := c.Client
+	configured := *previous
+	configured.Transport = t
+	c.Client = &configured
+
+	if pt, ok := previous.Transport.(*http.Transport); ok && pt != http.DefaultTransport {
+		pt.CloseIdleConnections()
+	}
+
+	return nil
+}
+
+// Transport returns a round tripper that sends requests over connections with the settings the client is configured
+// with at the time, so other HTTP clients, such as the one requesting OAuth 2.0 tokens, follow Configure too.
+func (c *Client) Transport() http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rt := c.httpClient().Transport
+		if rt == nil {
+			rt = http.DefaultTransport
+		}
+
+		return rt.RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// httpClient returns the HTTP client requests are currently sent with.
+func (c *Client) httpClient() *http.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.Client
+}
+
+// Do sends r and returns the response, following redirects as r asks for. The request is canceled if ctx is canceled,
+// or its timeout passes, before the response body has been read.
+func (c *Client) Do(ctx context.Context, r *request.Request) (*request.Response, error) {
+	if r == nil || r.Data == nil {
+		return nil, ErrNoData
+	}
+
+	if r.Data.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(r.Data.Timeout))
+		defer cancel()
+	}
+
+	d := r.Data
+	var hops []request.Hop
+	for {
+		ex, err := c.roundTrip(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+
+		next, err := redirect(ex, d)
+		if err != nil {
+			_ = ex.resp.Body.Close()
+			return nil, err
+		}
+		if next == nil {
+			return response(ex, hops)
+		}
+
+		_, _ = io.Copy(io.Discard, ex.resp.Body)
+		_ = ex.resp.Body.Close()
+
+		if len(hops) == d.Redirects.Limit() {
+			return nil, fmt.Errorf("stopped after %d redirects", len(hops))
+		}
+
+		hops = append(hops, hop(ex, time.Now()))
+		d = next
+	}
+}
+
+// roundTrip sends d, sending it again to answer the challenge of the server if it uses digest credentials. The caller
+// must close the body of the response.
+func (c *Client) roundTrip(ctx context.Context, d *request.Data) (*exchange, error) {
+	ex, err := c.send(ctx, d, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// digest credentials answer the challenge of the server, so the request is sent again once it has one
+	auth := d.Auth
+	if auth != nil && auth.Type == request.AuthDigest && ex.resp.StatusCode == http.StatusUnauthorized {
+		if challenge, ok := parseDigestChallenge(ex.resp); ok {
+			_, _ = io.Copy(io.Discard, ex.resp.Body)
+			_ = ex.resp.Body.Close()
+
+			authorization, err := challenge.authorize(ex.req, []byte(d.Body), auth.Username, auth.Password)
+			if err != nil {
+				return nil, fmt.Errorf("answering digest challenge: %w", err)
+			}
+
+			return c.send(ctx, d, authorization)
+		}
+	}
+
+	return ex, nil
+}
+
+// response reads the response received in ex, which was reached by following hops.
+func response(ex *exchange, hops []request.Hop) (*request.Response, error) {
+	resp := ex.resp
+	defer resp.Body.Close() // nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	end := time.Now()
+
+	rawHeaders, err := httputil.DumpResponse(resp, false)
+	if err != nil {
+		return nil, fmt.Errorf("dumping response: %w", err)
+	}
+
+	return &request.Response{
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		Proto:      resp.Proto,
+		Headers:    resp.Header,
+		Body:       body,
+		Timing:     ex.tracer.timing(ex.start, end),
+		RawRequest: string(ex.rawRequest),
+		RawHeaders: string(rawHeaders),
+		TLS:        tlsInfo(resp.TLS),
+		Redirects:  hops,
+	}, nil
+}
+
+// exchange is a request that has been sent, and the response to it.
+type exchange struct {
+	req        *http.Request
+	resp       *http.Response
+	rawRequest []byte
+	tracer     *tracer
+	start      time.Time
+}
+
+// send builds a request from d and sends it, replacing its Authorization header if authorization isn't empty. The
+// caller must close the body of the response.
+func (c *Client) send(ctx context.Context, d *request.Data, authorization string) (*exchange, error) {
+	req, err := NewHTTPRequest(ctx, d)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+	if auth := d.Auth; authorization == "" && auth != nil && auth.Type == request.AuthOAuth2 {
+		if c.Tokens == nil {
+			return nil, errors.New("building request: OAuth 2.0 is not available")
+		}
+
+		token, err := c.Tokens.Token(ctx, auth)
+		if err != nil {
+			return nil, err
+		}
+		authorization = token.Type() + " " + token.AccessToken
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	jar := c.Jar
+	if d.NoCookies {
+		jar = nil
+	}
+	if jar != nil {
+		for _, cookie := range jar.Cookies(req.URL) {
+			req.AddCookie(cookie)
+		}
+	}
+
+	slog.Debug("sending request", slog.String("method", req.Method), slog.String("url", req.URL.Redacted()))
+
+	rawRequest, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		return nil, fmt.Errorf("dumping request: %w", err)
+	}
+	rawRequest = redactCredentials(rawRequest)
+	proto, _ := request.NormalizeProto(d.Proto)
+	if proto == request.ProtoHTTP10 {
+		// requests are always dumped as HTTP/1.1, but the request line is the only difference
+		rawRequest = bytes.Replace(rawRequest, []byte(" HTTP/1.1\r\n"), []byte(" HTTP/1.0\r\n"), 1)
+	}
+
+	t := new(tracer)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), t.trace()))
+
+	// redirects are followed by Do, so each of them can be recorded and handled the way the request asks for
+	hc := *c.httpClient()
+	if hc.Transport, err = c.roundTripper(hc.Transport, proto); err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	hc.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	start := time.Now()
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+
+	if jar != nil {
+		jar.SetCookies(resp.Request.URL, resp.Cookies())
+	}
+
+	return &exchange{req: req, resp: resp, rawRequest: rawRequest, tracer: t, start: start}, nil
+}
+
+// NewHTTPRequest builds an *http.Request from d. Digest and OAuth 2.0 credentials are only added by Client.Do.
+func NewHTTPRequest(ctx context.Context, d *request.Data) (*http.Request, error) {
+	if d.URL == "" {
+		return nil, errors.New("missing URL")
+	}
+
+	method := strings.ToUpper(d.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if d.Body != "" {
+		body = strings.NewReader(d.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, d.URL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range d.Headers {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+
+	// the Host header is ignored by the transport, so it has to be set on the request itself
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+		req.Header.Del("Host")
+	}
+
+	proto, ok := request.NormalizeProto(d.Proto)
+	if !ok {
+		return nil, fmt.Errorf("invalid protocol %q", d.Proto)
+	}
+	switch proto {
+	case request.ProtoDefault:
+	case request.ProtoHTTP10:
+		req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/1.0", 1, 0
+	case request.ProtoHTTP11:
+		req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/1.1", 1, 1
+	case request.ProtoHTTP2, request.ProtoH2C:
+		if err := checkProto(proto, req.URL.Scheme); err != nil {
+			return nil, err
+		}
+		req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
+	}
+
+	// signatures cover the final URL and headers, so credentials are added last
+	if err := applyAuth(req, []byte(d.Body), d.Auth, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return req, nil
 }
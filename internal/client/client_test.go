@@ -0,0 +1,259 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/client"
+	"github.com/cstaaben/go-rest/internal/cookie"
+	"github.com/cstaaben/go-rest/internal/request"
+	"github.com/cstaaben/go-rest/internal/secrets"
+	"github.com/cstaaben/go-rest/internal/transport"
+)
+
+func TestClient_Do(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Host", r.Host)
+		w.Header().Set("X-Test", r.Header.Get("X-Test"))
+		w.Header().Set("X-Authorization", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	testCases := []struct {
+		name         string
+		data         *request.Data
+		expectedCode int
+		expectedBody string
+		expectedHdrs map[string]string
+		expectedRaw  string
+		expectingErr bool
+	}{
+		{
+			name: "Default method",
+			data: &request.Data{
+				URL: srv.URL,
+			},
+			expectedCode: http.StatusCreated,
+			expectedHdrs: map[string]string{"X-Method": http.MethodGet},
+		},
+		{
+			name: "Method, headers and body",
+			data: &request.Data{
+				URL:     srv.URL + "/path",
+				Method:  "post",
+				Headers: map[string][]string{"X-Test": {"value"}, "Host": {"example.com"}},
+				Body:    `{"hello":"world"}`,
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"hello":"world"}`,
+			expectedHdrs: map[string]string{
+				"X-Method": http.MethodPost,
+				"X-Test":   "value",
+				"X-Host":   "example.com",
+			},
+		},
+		{
+			name: "Basic auth",
+			data: &request.Data{
+				URL:  srv.URL,
+				Auth: &request.Auth{Type: request.AuthBasic, Username: "user", Password: "pass"},
+			},
+			expectedCode: http.StatusCreated,
+			expectedHdrs: map[string]string{"X-Authorization": "Basic dXNlcjpwYXNz"},
+			expectedRaw:  "Authorization: Basic " + secrets.Masked,
+		},
+		{
+			name: "Bearer auth",
+			data: &request.Data{
+				URL:  srv.URL,
+				Auth: &request.Auth{Type: request.AuthBearer, Token: "t0k3n"},
+			},
+			expectedCode: http.StatusCreated,
+			expectedHdrs: map[string]string{"X-Authorization": "Bearer t0k3n"},
+			expectedRaw:  "Authorization: Bearer " + secrets.Masked,
+		},
+		{
+			name:         "Unsupported auth",
+			data:         &request.Data{URL: srv.URL, Auth: &request.Auth{Type: "magic"}},
+			expectingErr: true,
+		},
+		{
+			name:         "Missing URL",
+			data:         &request.Data{Method: http.MethodGet},
+			expectingErr: true,
+		},
+		{
+			name:         "Invalid protocol",
+			data:         &request.Data{URL: srv.URL, Proto: "HTTP/one"},
+			expectingErr: true,
+		},
+	}
+
+	c := client.New()
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				resp, err := c.Do(context.Background(), &request.Request{Name: tc.name, Data: tc.data})
+				if tc.expectingErr {
+					assert.Error(t, err)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedCode, resp.StatusCode)
+				assert.Equal(t, "HTTP/1.1", resp.Proto)
+				assert.Equal(t, tc.expectedBody, string(resp.Body))
+				assert.Equal(t, "text/plain", resp.ContentType())
+				assert.False(t, resp.Timing.Start.IsZero())
+				assert.Positive(t, resp.Timing.Total)
+				assert.Contains(t, resp.RawRequest, tc.expectedHdrs["X-Method"]+" /")
+				assert.Contains(t, resp.RawHeaders, "HTTP/1.1 201 Created")
+				assert.Contains(t, resp.RawRequest, tc.expectedRaw)
+				if auth := http.Header(resp.Headers).Get("X-Authorization"); auth != "" {
+					// encoded credentials are never kept, even when they aren't a secret themselves
+					assert.NotContains(t, resp.RawRequest, auth)
+				}
+				if tc.data.Body != "" {
+					assert.True(t, strings.HasSuffix(resp.RawRequest, "\r\n\r\n"+tc.data.Body))
+				}
+
+				for name, value := range tc.expectedHdrs {
+					assert.Equal(t, value, http.Header(resp.Headers).Get(name), name)
+				}
+			},
+		)
+	}
+}
+
+func TestClient_DoCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.New().Do(ctx, &request.Request{Data: &request.Data{URL: srv.URL}})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestClient_DoTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	d := &request.Data{URL: srv.URL, Timeout: request.Duration(10 * time.Millisecond)}
+	_, err := client.New().Do(context.Background(), &request.Request{Data: d})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClient_DoCookies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Cookie", r.Header.Get("Cookie"))
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: r.URL.Query().Get("session")})
+	}))
+	defer srv.Close()
+
+	jar := cookie.New(t.TempDir())
+	c := client.New(client.WithCookies(jar))
+
+	send := func(session string, noCookies bool) string {
+		d := &request.Data{URL: srv.URL + "/?session=" + session, NoCookies: noCookies}
+		resp, err := c.Do(context.Background(), &request.Request{Data: d})
+		require.NoError(t, err)
+		return http.Header(resp.Headers).Get("X-Cookie")
+	}
+
+	assert.Empty(t, send("1", false))
+	assert.Equal(t, "session=1", send("2", false))
+	// requests opting out neither send nor store cookies
+	assert.Empty(t, send("3", true))
+	assert.Equal(t, "session=2", send("4", false))
+}
+
+func TestClient_DoNoData(t *testing.T) {
+	_, err := client.New().Do(context.Background(), &request.Request{})
+	assert.ErrorIs(t, err, client.ErrNoData)
+}
+
+func TestClient_DoTiming(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := client.New(client.WithHTTPClient(srv.Client()))
+	r := &request.Request{Data: &request.Data{URL: srv.URL}}
+
+	resp, err := c.Do(context.Background(), r)
+	require.NoError(t, err)
+
+	timing := resp.Timing
+	assert.False(t, timing.Reused)
+	assert.Positive(t, timing.Connect)
+	assert.Positive(t, timing.TLS)
+	assert.Positive(t, timing.Wait)
+	assert.LessOrEqual(t, timing.Connect+timing.TLS+timing.Wait, timing.Total)
+
+	resp, err = c.Do(context.Background(), r)
+	require.NoError(t, err)
+	assert.True(t, resp.Timing.Reused)
+	assert.Zero(t, resp.Timing.Connect)
+	assert.Zero(t, resp.Timing.TLS)
+}
+
+func TestClient_Configure(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := client.New()
+	r := &request.Request{Data: &request.Data{URL: srv.URL}}
+
+	_, err := c.Do(context.Background(), r)
+	assert.ErrorContains(t, err, "certificate", "the test server isn't trusted by default")
+
+	// other clients sending over the transport of the client follow its configuration
+	other := &http.Client{Transport: c.Transport()}
+	_, err = other.Get(srv.URL)
+	assert.ErrorContains(t, err, "certificate")
+
+	insecure := true
+	require.NoError(t, c.Configure(transport.Settings{TLS: transport.TLS{Insecure: &insecure}}))
+
+	otherResp, err := other.Get(srv.URL)
+	require.NoError(t, err)
+	_ = otherResp.Body.Close()
+
+	resp, err := c.Do(context.Background(), r)
+	require.NoError(t, err)
+	require.NotNil(t, resp.TLS)
+	assert.Equal(t, "TLS 1.3", resp.TLS.Version)
+	assert.NotEmpty(t, resp.TLS.CipherSuite)
+	require.Len(t, resp.TLS.Certificates, 1)
+	assert.Contains(t, resp.TLS.Certificates[0].DNSNames, "example.com")
+
+	// invalid settings leave the client as it was
+	assert.Error(t, c.Configure(transport.Settings{TLS: transport.TLS{MinVersion: "2.0"}}))
+	_, err = c.Do(context.Background(), r)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,177 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package client
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// digestChallenge is the challenge a server using digest authentication (RFC 7616) answers unauthenticated requests
+// with.
+type digestChallenge struct {
+	realm, nonce, opaque, algorithm string
+	qop                             []string
+}
+
+// parseDigestChallenge finds the digest challenge in the WWW-Authenticate headers of resp.
+func parseDigestChallenge(resp *http.Response) (*digestChallenge, bool) {
+	for _, header := range resp.Header.Values("WWW-Authenticate") {
+		scheme, params, _ := strings.Cut(strings.TrimSpace(header), " ")
+		if !strings.EqualFold(scheme, "Digest") {
+			continue
+		}
+
+		values := parseAuthParams(params)
+		c := &digestChallenge{
+			realm:     values["realm"],
+			nonce:     values["nonce"],
+			opaque:    values["opaque"],
+			algorithm: values["algorithm"],
+		}
+		for _, qop := range strings.Split(values["qop"], ",") {
+			if qop = strings.TrimSpace(qop); qop != "" {
+				c.qop = append(c.qop, qop)
+			}
+		}
+
+		return c, c.nonce != ""
+	}
+
+	return nil, false
+}
+
+// parseAuthParams parses the comma separated name=value pairs of an authentication challenge. Values may be quoted.
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for s != "" {
+		var name string
+		name, s, _ = strings.Cut(s, "=")
+		name = strings.ToLower(strings.Trim(name, " ,"))
+
+		var value string
+		s = strings.TrimLeft(s, " ")
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			value, s = b.String(), s[min(i+1, len(s)):]
+		} else {
+			value, s, _ = strings.Cut(s, ",")
+			value = strings.TrimSpace(value)
+		}
+
+		if name != "" {
+			params[name] = value
+		}
+		s = strings.TrimLeft(s, " ,")
+	}
+
+	return params
+}
+
+// authorize answers the challenge for req with the given credentials, returning the value of its Authorization header.
+// The body must be the body req sends.
+func (c *digestChallenge) authorize(req *http.Request, body []byte, username, password string) (string, error) {
+	algorithm := strings.ToUpper(c.algorithm)
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+
+	var newHash func() hash.Hash
+	switch strings.TrimSuffix(algorithm, "-SESS") {
+	case "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm %q", c.algorithm)
+	}
+
+	h := func(parts ...string) string {
+		digest := newHash()
+		digest.Write([]byte(strings.Join(parts, ":")))
+		return hex.EncodeToString(digest.Sum(nil))
+	}
+
+	cnonceBytes := make([]byte, 16)
+	if _, err := rand.Read(cnonceBytes); err != nil {
+		return "", fmt.Errorf("generating client nonce: %w", err)
+	}
+	cnonce := hex.EncodeToString(cnonceBytes)
+	const nc = "00000001"
+
+	ha1 := h(username, c.realm, password)
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = h(ha1, c.nonce, cnonce)
+	}
+
+	var qop string
+	switch {
+	case slices.Contains(c.qop, "auth"):
+		qop = "auth"
+	case slices.Contains(c.qop, "auth-int"):
+		qop = "auth-int"
+	case len(c.qop) > 0:
+		return "", fmt.Errorf("unsupported digest qop %q", strings.Join(c.qop, ","))
+	}
+
+	uri := req.URL.RequestURI()
+	ha2 := h(req.Method, uri)
+	if qop == "auth-int" {
+		ha2 = h(req.Method, uri, h(string(body)))
+	}
+
+	params := []string{
+		fmt.Sprintf("username=%q", username),
+		fmt.Sprintf("realm=%q", c.realm),
+		fmt.Sprintf("nonce=%q", c.nonce),
+		fmt.Sprintf("uri=%q", uri),
+		"algorithm=" + algorithm,
+	}
+
+	if qop == "" {
+		params = append(params, fmt.Sprintf("response=%q", h(ha1, c.nonce, ha2)))
+	} else {
+		params = append(params,
+			fmt.Sprintf("response=%q", h(ha1, c.nonce, nc, cnonce, qop, ha2)),
+			"qop="+qop,
+			"nc="+nc,
+			fmt.Sprintf("cnonce=%q", cnonce),
+		)
+	}
+
+	if c.opaque != "" {
+		params = append(params, fmt.Sprintf("opaque=%q", c.opaque))
+	}
+
+	return "Digest " + strings.Join(params, ", "), nil
+}
@@ -0,0 +1,278 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+// roundTripper returns the round tripper that sends requests with proto, derived from base. The round trippers are kept
+// until base changes, so their connections can be reused.
+func (c *Client) roundTripper(base http.RoundTripper, proto string) (http.RoundTripper, error) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if proto == request.ProtoDefault {
+		return base, nil
+	}
+
+	t, ok := base.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("can't select the protocol of requests sent with %T", base)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.protocolsOf != base {
+		for _, rt := range c.protocols {
+			if pt, ok := rt.(*http.Transport); ok {
+				pt.CloseIdleConnections()
+			}
+		}
+		c.protocols, c.protocolsOf = make(map[string]http.RoundTripper), base
+	}
+	if rt, ok := c.protocols[proto]; ok {
+		return rt, nil
+	}
+
+	derived := t.Clone()
+	protocols := new(http.Protocols)
+	if derived.TLSClientConfig == nil {
+		derived.TLSClientConfig = new(tls.Config)
+	}
+
+	var rt http.RoundTripper = derived
+	switch proto {
+	case request.ProtoHTTP10:
+		rt = &http10Transport{base: derived}
+	case request.ProtoHTTP11:
+		protocols.SetHTTP1(true)
+		// a TLS config offering h2 itself would negotiate a protocol the transport doesn't speak
+		derived.TLSClientConfig.NextProtos = []string{"http/1.1"}
+	case request.ProtoHTTP2:
+		protocols.SetHTTP2(true)
+		derived.TLSClientConfig.NextProtos = []string{"h2"}
+	case request.ProtoH2C:
+		protocols.SetUnencryptedHTTP2(true)
+	default:
+		return nil, fmt.Errorf("unsupported protocol %q", proto)
+	}
+	derived.Protocols = protocols
+
+	c.protocols[proto] = rt
+	return rt, nil
+}
+
+// checkProto returns an error if proto can't be used to send a request to a URL with the given scheme.
+func checkProto(proto, scheme string) error {
+	switch {
+	case proto == request.ProtoHTTP2 && scheme != "https":
+		return errors.New("HTTP/2 is only negotiated over TLS, use h2c for http:// URLs")
+	case proto == request.ProtoH2C && scheme != "http":
+		return errors.New("h2c is only sent over cleartext connections, use HTTP/2 for https:// URLs")
+	}
+
+	return nil
+}
+
+// http10Transport sends requests with HTTP/1.0, which http.Transport always upgrades to HTTP/1.1. Each request is sent
+// over a new connection, which is closed with the response body.
+type http10Transport struct {
+	base *http.Transport
+}
+
+func (t *http10Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.base.Proxy != nil {
+		proxyURL, err := t.base.Proxy(req)
+		if err != nil {
+			return nil, err
+		}
+		if proxyURL != nil {
+			return nil, errors.New("HTTP/1.0 requests can't be sent through a proxy")
+		}
+	}
+
+	ctx := req.Context()
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace == nil {
+		trace = new(httptrace.ClientTrace)
+	}
+
+	conn, state, err := t.dial(ctx, req, trace)
+	if err != nil {
+		return nil, err
+	}
+	if trace.GotConn != nil {
+		trace.GotConn(httptrace.GotConnInfo{Conn: conn})
+	}
+
+	// the connection is closed to cancel the request, as there is no other way to interrupt reading from it
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	fail := func(err error) (*http.Response, error) {
+		stop()
+		_ = conn.Close()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+
+	err = writeHTTP10(conn, req)
+	if trace.WroteRequest != nil {
+		trace.WroteRequest(httptrace.WroteRequestInfo{Err: err})
+	}
+	if err != nil {
+		return fail(fmt.Errorf("writing request: %w", err))
+	}
+
+	br := bufio.NewReader(conn)
+	if _, err := br.Peek(1); err != nil {
+		return fail(fmt.Errorf("reading response: %w", err))
+	}
+	if trace.GotFirstResponseByte != nil {
+		trace.GotFirstResponseByte()
+	}
+
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return fail(fmt.Errorf("reading response: %w", err))
+	}
+	resp.TLS = state
+	resp.Body = &connBody{ReadCloser: resp.Body, conn: conn, stop: stop}
+
+	return resp, nil
+}
+
+// dial connects to the server of req, with TLS for https URLs.
+func (t *http10Transport) dial(
+	ctx context.Context, req *http.Request, trace *httptrace.ClientTrace,
+) (net.Conn, *tls.ConnectionState, error) {
+	dial := t.base.DialContext
+	if dial == nil {
+		dial = new(net.Dialer).DialContext
+	}
+
+	addr := net.JoinHostPort(req.URL.Hostname(), port(req.URL))
+	conn, err := dial(ctx, "tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.URL.Scheme != "https" {
+		return conn, nil, nil
+	}
+
+	config := new(tls.Config)
+	if t.base.TLSClientConfig != nil {
+		config = t.base.TLSClientConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = req.URL.Hostname()
+	}
+	// ALPN only offers HTTP/1.1 and later, so no protocol is negotiated
+	config.NextProtos = nil
+
+	if trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	tlsConn := tls.Client(conn, config)
+	err = tlsConn.HandshakeContext(ctx)
+	state := tlsConn.ConnectionState()
+	if trace.TLSHandshakeDone != nil {
+		trace.TLSHandshakeDone(state, err)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+
+	return tlsConn, &state, nil
+}
+
+// writeHTTP10 writes req to w as an HTTP/1.0 request.
+func writeHTTP10(w io.Writer, req *http.Request) error {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	header := req.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("Host", host)
+	if _, ok := header["User-Agent"]; !ok {
+		header.Set("User-Agent", "Go-http-client/1.0")
+	}
+	if len(body) > 0 {
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := fmt.Fprintf(bw, "%s %s HTTP/1.0\r\n", req.Method, req.URL.RequestURI()); err != nil {
+		return err
+	}
+	if err := header.Write(bw); err != nil {
+		return err
+	}
+	if _, err := bw.WriteString("\r\n"); err != nil {
+		return err
+	}
+	if _, err := bw.Write(body); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// connBody is the body of a response that closes the connection it is read from when it is closed.
+type connBody struct {
+	io.ReadCloser
+	conn net.Conn
+	stop func() bool
+}
+
+func (b *connBody) Close() error {
+	b.stop()
+	err := b.ReadCloser.Close()
+	_ = b.conn.Close()
+
+	return err
+}
@@ -0,0 +1,88 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/client"
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+func TestClient_DoProto(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Proto", r.Proto)
+		_, _ = w.Write(body)
+	})
+
+	tlsServer := httptest.NewUnstartedServer(handler)
+	tlsServer.EnableHTTP2 = true
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+
+	cleartext := httptest.NewUnstartedServer(handler)
+	cleartext.Config.Protocols = new(http.Protocols)
+	cleartext.Config.Protocols.SetHTTP1(true)
+	cleartext.Config.Protocols.SetUnencryptedHTTP2(true)
+	cleartext.Start()
+	defer cleartext.Close()
+
+	testCases := []struct {
+		name         string
+		url          string
+		proto        string
+		expected     string
+		expectedALPN string
+		expectingErr string
+	}{
+		{name: "Default over TLS", url: tlsServer.URL, expected: "HTTP/2.0", expectedALPN: "h2"},
+		{name: "Default over cleartext", url: cleartext.URL, expected: "HTTP/1.1"},
+		{name: "HTTP/2", url: tlsServer.URL, proto: request.ProtoHTTP2, expected: "HTTP/2.0", expectedALPN: "h2"},
+		{name: "HTTP/1.1 over TLS", url: tlsServer.URL, proto: request.ProtoHTTP11, expected: "HTTP/1.1"},
+		{name: "HTTP/1.0 over TLS", url: tlsServer.URL, proto: request.ProtoHTTP10, expected: "HTTP/1.0"},
+		{name: "HTTP/1.0 over cleartext", url: cleartext.URL, proto: request.ProtoHTTP10, expected: "HTTP/1.0"},
+		{name: "h2c", url: cleartext.URL, proto: request.ProtoH2C, expected: "HTTP/2.0"},
+		{name: "HTTP/2.0 alias", url: tlsServer.URL, proto: "HTTP/2.0", expected: "HTTP/2.0", expectedALPN: "h2"},
+		{name: "h2 alias", url: tlsServer.URL, proto: "H2", expected: "HTTP/2.0", expectedALPN: "h2"},
+		{name: "H2C alias", url: cleartext.URL, proto: "H2C", expected: "HTTP/2.0"},
+		{name: "Lowercase HTTP/1.0", url: cleartext.URL, proto: "http/1.0", expected: "HTTP/1.0"},
+		{name: "HTTP/2 over cleartext", url: cleartext.URL, proto: request.ProtoHTTP2, expectingErr: "use h2c"},
+		{name: "h2c over TLS", url: tlsServer.URL, proto: request.ProtoH2C, expectingErr: "use HTTP/2"},
+		{name: "Unknown protocol", url: cleartext.URL, proto: "HTTP/3", expectingErr: `invalid protocol "HTTP/3"`},
+	}
+
+	// the client of the TLS server trusts its certificate, and works for the cleartext server too
+	c := client.New(client.WithHTTPClient(tlsServer.Client()))
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				d := &request.Data{URL: tc.url, Method: http.MethodPost, Proto: tc.proto, Body: "payload"}
+
+				resp, err := c.Do(context.Background(), &request.Request{Data: d})
+				if tc.expectingErr != "" {
+					assert.ErrorContains(t, err, tc.expectingErr)
+					return
+				}
+				require.NoError(t, err)
+
+				// both the protocol the server received and the one it answered with are the one asked for
+				assert.Equal(t, tc.expected, http.Header(resp.Headers).Get("X-Proto"))
+				assert.Equal(t, tc.expected, resp.Proto)
+				assert.Equal(t, "payload", string(resp.Body))
+
+				if tc.expectedALPN != "" {
+					require.NotNil(t, resp.TLS)
+					assert.Equal(t, tc.expectedALPN, resp.TLS.Protocol)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,124 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+// redirect returns the request data that follows the redirect received in ex, or nil if ex isn't a redirect or
+// redirects aren't followed for d.
+func redirect(ex *exchange, d *request.Data) (*request.Data, error) {
+	policy := d.Redirects
+	if policy == nil {
+		policy = new(request.Redirects)
+	}
+	if !policy.Following() {
+		return nil, nil
+	}
+
+	switch ex.resp.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return nil, nil
+	}
+
+	location := ex.resp.Header.Get("Location")
+	if location == "" {
+		return nil, nil
+	}
+
+	target, err := ex.req.URL.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("following redirect to %q: %w", location, err)
+	}
+
+	next := d.Clone()
+	next.URL = target.String()
+
+	// like browsers, the method changes to GET unless the redirect asks for it to be kept with a 307 or 308
+	method := ex.req.Method
+	changesMethod := ex.resp.StatusCode != http.StatusTemporaryRedirect &&
+		ex.resp.StatusCode != http.StatusPermanentRedirect
+	if changesMethod && !policy.PreserveMethod && method != http.MethodGet && method != http.MethodHead {
+		next.Method = http.MethodGet
+		next.Body = ""
+		deleteHeader(next.Headers, "Content-Type")
+		deleteHeader(next.Headers, "Content-Length")
+	}
+
+	if origin(ex.req.URL) != origin(target) {
+		// a Host header set for the original server would send the request back to it
+		deleteHeader(next.Headers, "Host")
+
+		if !policy.PreserveAuth {
+			next.Auth = nil
+			deleteHeader(next.Headers, "Authorization")
+			deleteHeader(next.Headers, "Cookie")
+		}
+	}
+
+	return next, nil
+}
+
+// hop describes the redirect received in ex.
+func hop(ex *exchange, end time.Time) request.Hop {
+	return request.Hop{
+		Method:     ex.req.Method,
+		URL:        ex.req.URL.String(),
+		Status:     ex.resp.Status,
+		StatusCode: ex.resp.StatusCode,
+		Location:   ex.resp.Header.Get("Location"),
+		Timing:     ex.tracer.timing(ex.start, end),
+	}
+}
+
+// origin returns the scheme, host and port of u, which have to match for requests to go to the same server.
+func origin(u *url.URL) string {
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Hostname()) + ":" + port(u)
+}
+
+// port returns the port of u, or the default port of its scheme.
+func port(u *url.URL) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+
+	if strings.EqualFold(u.Scheme, "https") {
+		return "443"
+	}
+
+	return "80"
+}
+
+// deleteHeader removes every header called name from headers, whatever the case of its name.
+func deleteHeader(headers map[string][]string, name string) {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			delete(headers, key)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/client"
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+func TestClient_DoRedirects(t *testing.T) {
+	var other *httptest.Server
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/found":
+			http.Redirect(w, r, "/echo", http.StatusFound)
+		case "/temporary":
+			http.Redirect(w, r, "/found", http.StatusTemporaryRedirect)
+		case "/cross":
+			http.Redirect(w, r, other.URL+"/echo", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusMovedPermanently)
+		default:
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("X-Method", r.Method)
+			w.Header().Set("X-Body", string(body))
+			w.Header().Set("X-Authorization", r.Header.Get("Authorization"))
+			w.Header().Set("X-Custom", r.Header.Get("X-Custom"))
+		}
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	other = httptest.NewServer(handler)
+	defer other.Close()
+
+	follow := false
+	testCases := []struct {
+		name           string
+		path           string
+		method         string
+		redirects      *request.Redirects
+		expectedStatus int
+		expectedMethod string
+		expectedBody   string
+		expectedAuth   string
+		expectedHops   []string
+		expectingErr   string
+	}{
+		{
+			name:           "Method changed to GET",
+			path:           "/found",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusOK,
+			expectedMethod: http.MethodGet,
+			expectedAuth:   "Bearer token",
+			expectedHops:   []string{"302 Found POST /found → /echo"},
+		},
+		{
+			name:           "Method preserved",
+			path:           "/found",
+			method:         http.MethodPost,
+			redirects:      &request.Redirects{PreserveMethod: true},
+			expectedStatus: http.StatusOK,
+			expectedMethod: http.MethodPost,
+			expectedBody:   "payload",
+			expectedAuth:   "Bearer token",
+			expectedHops:   []string{"302 Found POST /found → /echo"},
+		},
+		{
+			name:           "Temporary redirect keeps the method for one hop",
+			path:           "/temporary",
+			method:         http.MethodPut,
+			expectedStatus: http.StatusOK,
+			expectedMethod: http.MethodGet,
+			expectedAuth:   "Bearer token",
+			expectedHops: []string{
+				"307 Temporary Redirect PUT /temporary → /found",
+				"302 Found PUT /found → /echo",
+			},
+		},
+		{
+			name:           "Credentials dropped across origins",
+			path:           "/cross",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedMethod: http.MethodGet,
+			expectedBody:   "payload",
+			expectedHops:   []string{"302 Found GET /cross → " + other.URL + "/echo"},
+		},
+		{
+			name:           "Credentials preserved across origins",
+			path:           "/cross",
+			method:         http.MethodGet,
+			redirects:      &request.Redirects{PreserveAuth: true},
+			expectedStatus: http.StatusOK,
+			expectedMethod: http.MethodGet,
+			expectedBody:   "payload",
+			expectedAuth:   "Bearer token",
+			expectedHops:   []string{"302 Found GET /cross → " + other.URL + "/echo"},
+		},
+		{
+			name:           "Not followed",
+			path:           "/found",
+			method:         http.MethodGet,
+			redirects:      &request.Redirects{Follow: &follow},
+			expectedStatus: http.StatusFound,
+		},
+		{
+			name:         "Too many redirects",
+			path:         "/loop",
+			method:       http.MethodGet,
+			redirects:    &request.Redirects{Max: 3},
+			expectingErr: "stopped after 3 redirects",
+		},
+	}
+
+	c := client.New()
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				d := &request.Data{
+					URL:       srv.URL + tc.path,
+					Method:    tc.method,
+					Body:      "payload",
+					Headers:   map[string][]string{"X-Custom": {"kept"}},
+					Auth:      &request.Auth{Type: request.AuthBearer, Token: "token"},
+					Redirects: tc.redirects,
+				}
+
+				resp, err := c.Do(context.Background(), &request.Request{Data: d})
+				if tc.expectingErr != "" {
+					assert.ErrorContains(t, err, tc.expectingErr)
+					return
+				}
+				require.NoError(t, err)
+
+				assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+				if tc.expectedStatus == http.StatusOK {
+					headers := http.Header(resp.Headers)
+					assert.Equal(t, tc.expectedMethod, headers.Get("X-Method"))
+					assert.Equal(t, tc.expectedBody, headers.Get("X-Body"))
+					assert.Equal(t, tc.expectedAuth, headers.Get("X-Authorization"))
+					assert.Equal(t, "kept", headers.Get("X-Custom"))
+				}
+
+				var hops []string
+				for _, hop := range resp.Redirects {
+					assert.Positive(t, hop.Timing.Total)
+					hops = append(hops, hop.Status+" "+hop.Method+" "+hop.URL[len(srv.URL):]+" → "+hop.Location)
+				}
+				assert.Equal(t, tc.expectedHops, hops)
+			},
+		)
+	}
+}
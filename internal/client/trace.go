@@ -0,0 +1,99 @@
+package client
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+// tracer records when each phase of a request starts and ends.
+type tracer struct {
+	mu sync.Mutex
+
+	dnsStart, dnsDone         time.Time
+	connectStart, connectDone time.Time
+	tlsStart, tlsDone         time.Time
+	gotConn                   time.Time
+	wroteRequest              time.Time
+	firstByte                 time.Time
+	reused                    bool
+}
+
+// trace returns the hooks that record the phases of a request in t.
+func (t *tracer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.record(&t.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.record(&t.dnsDone)
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			// multiple addresses may be dialed in parallel, so only the first start is kept
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(string, string, error) {
+			t.record(&t.connectDone)
+		},
+		TLSHandshakeStart: func() {
+			t.record(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.record(&t.tlsDone)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.gotConn = time.Now()
+			t.reused = info.Reused
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.record(&t.wroteRequest)
+		},
+		GotFirstResponseByte: func() {
+			t.record(&t.firstByte)
+		},
+	}
+}
+
+func (t *tracer) record(field *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	*field = time.Now()
+}
+
+// timing returns the durations of each phase of a request that started at start and whose response body was read
+// completely at end.
+func (t *tracer) timing(start, end time.Time) request.Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return request.Timing{
+		Start:    start,
+		DNS:      between(t.dnsStart, t.dnsDone),
+		Connect:  between(t.connectStart, t.connectDone),
+		TLS:      between(t.tlsStart, t.tlsDone),
+		Send:     between(t.gotConn, t.wroteRequest),
+		Wait:     between(t.wroteRequest, t.firstByte),
+		Transfer: between(t.firstByte, end),
+		Total:    end.Sub(start),
+		Reused:   t.reused,
+	}
+}
+
+// between returns the time from start to end, or zero if either didn't happen.
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+
+	return end.Sub(start)
+}
@@ -23,10 +23,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
+	"time"
 
 	gap "github.com/muesli/go-app-paths"
 	flag "github.com/spf13/pflag"
 	"github.com/spf13/viper"
+
+	"github.com/cstaaben/go-rest/internal/transport"
 )
 
 const (
@@ -50,6 +54,12 @@ type Config struct {
 	ColorScheme string `json:"color_scheme,omitempty" mapstructure:"color_scheme"`
 	// Log is the configuration for logging.
 	Log Log `json:"log,omitempty" mapstructure:"log"`
+	// Secrets is the configuration for the secrets vault.
+	Secrets Secrets `json:"secrets,omitempty" mapstructure:"secrets"`
+	// DependencyTTL is how long the response of a prerequisite request is reused before it is sent again.
+	DependencyTTL time.Duration `json:"dependency_ttl,omitempty" mapstructure:"dependency_ttl"`
+	// Transport is the configuration of the connections requests are sent over, which environments can override.
+	Transport transport.Settings `json:"transport,omitempty" mapstructure:"transport"`
 }
 
 // Log contains all configuration options for logging.
@@ -62,6 +72,14 @@ type Log struct {
 	Format string `json:"format,omitempty" mapstructure:"format"`
 }
 
+// Secrets contains the configuration of the encrypted vault secret variables are stored in.
+type Secrets struct {
+	// Path is the full filepath of the vault. By default, this is secrets.vault in the data directory.
+	Path string `json:"path,omitempty" mapstructure:"path"`
+	// KeyFile is a file containing the passphrase of the vault, used if $GO_REST_VAULT_PASSPHRASE is not set.
+	KeyFile string `json:"key_file,omitempty" mapstructure:"key_file"`
+}
+
 // Load reads the file at configFile and parses it.
 func Load() error {
 	err := viper.BindPFlag("config", flag.Lookup("config"))
@@ -87,6 +105,11 @@ func Load() error {
 
 	config.DataDir = os.ExpandEnv(config.DataDir)
 	config.Log.Path = os.ExpandEnv(config.Log.Path)
+	config.Secrets.Path = os.ExpandEnv(config.Secrets.Path)
+	config.Secrets.KeyFile = os.ExpandEnv(config.Secrets.KeyFile)
+	if config.Secrets.Path == "" {
+		config.Secrets.Path = path.Join(config.DataDir, "secrets.vault")
+	}
 
 	return nil
 }
@@ -124,6 +147,9 @@ func setDefaults() error {
 	// log format
 	viper.SetDefault("log.format", "json")
 
+	// prerequisite responses
+	viper.SetDefault("dependency_ttl", 5*time.Minute)
+
 	return nil
 }
 
@@ -131,10 +157,26 @@ func Logging() Log {
 	return config.Log
 }
 
+func Vault() Secrets {
+	return config.Secrets
+}
+
 func DataDir() string {
 	return config.DataDir
 }
 
+func DependencyTTL() time.Duration {
+	return config.DependencyTTL
+}
+
+func Transport() transport.Settings {
+	return config.Transport
+}
+
+func DefaultEnv() string {
+	return config.DefaultEnv
+}
+
 func ColorScheme() string {
 	return config.ColorScheme
 }
@@ -0,0 +1,365 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Package cookie keeps the cookies set by responses, separately for each environment, and persists them to the data
+// directory so sessions survive restarts.
+package cookie
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+
+	"github.com/cstaaben/go-rest/internal/fileutil"
+)
+
+// Dir is the name of the directory in the data directory that cookies are persisted to.
+const Dir = "cookies"
+
+// Cookie is a cookie stored in the jar.
+type Cookie struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Domain string `json:"domain"`
+	Path   string `json:"path"`
+	// Expires is when the cookie expires. It is zero for session cookies, which are kept until they are deleted.
+	Expires  time.Time `json:"expires,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+	HTTPOnly bool      `json:"http_only,omitempty"`
+	// HostOnly cookies are only sent to their domain itself, not its subdomains.
+	HostOnly bool `json:"host_only,omitempty"`
+}
+
+// expired reports whether the cookie has expired at now.
+func (c *Cookie) expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !c.Expires.After(now)
+}
+
+// matches reports whether the cookie is sent with requests to u.
+func (c *Cookie) matches(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	if c.HostOnly && host != c.Domain || !c.HostOnly && !domainMatch(host, c.Domain) {
+		return false
+	}
+
+	if c.Secure && u.Scheme != "https" {
+		return false
+	}
+
+	return pathMatch(u.EscapedPath(), c.Path)
+}
+
+type Option func(*Jar)
+
+// WithClock takes the current time used to expire cookies from now.
+func WithClock(now func() time.Time) Option {
+	return func(j *Jar) {
+		j.now = now
+	}
+}
+
+// Jar is an http.CookieJar that keeps the cookies of each environment apart. Cookies are stored for the selected
+// environment and written to a file named after it, except while no environment is selected, in which case they are
+// only kept in memory.
+type Jar struct {
+	dir string
+	now func() time.Time
+
+	mu      sync.Mutex
+	env     string
+	cookies map[string][]*Cookie
+}
+
+// New creates a jar persisting cookies to dir and applies the provided options.
+func New(dir string, opts ...Option) *Jar {
+	j := &Jar{
+		dir:     dir,
+		now:     time.Now,
+		cookies: make(map[string][]*Cookie),
+	}
+
+	for _, optFunc := range opts {
+		optFunc(j)
+	}
+
+	return j
+}
+
+// Select uses the cookies of the environment with the given name from now on, loading them from disk the first time.
+func (j *Jar) Select(env string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.env = env
+	if _, ok := j.cookies[env]; ok || env == "" {
+		return nil
+	}
+
+	body, err := os.ReadFile(j.path(env))
+	if errors.Is(err, os.ErrNotExist) {
+		j.cookies[env] = nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading cookies of %s: %w", env, err)
+	}
+
+	var cookies []*Cookie
+	if err := json.Unmarshal(body, &cookies); err != nil {
+		return fmt.Errorf("parsing cookies of %s: %w", env, err)
+	}
+	j.cookies[env] = cookies
+
+	return nil
+}
+
+// SetCookies stores the cookies set by a response from u, implementing http.CookieJar.
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if len(cookies) == 0 {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := j.now()
+	host := strings.ToLower(u.Hostname())
+	for _, hc := range cookies {
+		c := &Cookie{
+			Name:     hc.Name,
+			Value:    hc.Value,
+			Domain:   strings.ToLower(strings.TrimPrefix(hc.Domain, ".")),
+			Path:     hc.Path,
+			Secure:   hc.Secure,
+			HTTPOnly: hc.HttpOnly,
+		}
+
+		switch {
+		case c.Domain == "":
+			c.Domain, c.HostOnly = host, true
+		case publicsuffix.List.PublicSuffix(c.Domain) == c.Domain:
+			// like browsers, cookies for a public suffix such as co.uk are only kept for the host itself, so one site
+			// can't set cookies for every other site sharing the suffix
+			if c.Domain != host {
+				continue
+			}
+			c.HostOnly = true
+		case c.Domain == host:
+		case !domainMatch(host, c.Domain) || net.ParseIP(host) != nil:
+			// a server can't set cookies for other domains
+			continue
+		}
+
+		if !strings.HasPrefix(c.Path, "/") {
+			c.Path = defaultPath(u.EscapedPath())
+		}
+
+		switch {
+		case hc.MaxAge < 0:
+			c.Expires = now
+		case hc.MaxAge > 0:
+			c.Expires = now.Add(time.Duration(hc.MaxAge) * time.Second)
+		default:
+			c.Expires = hc.Expires
+		}
+
+		j.store(c)
+	}
+
+	if err := j.save(); err != nil {
+		slog.Warn("saving cookies", slog.Any("error", err))
+	}
+}
+
+// Cookies returns the cookies to send with a request to u, implementing http.CookieJar. Cookies with longer paths are
+// sent first.
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := j.now()
+
+	var matching []*Cookie
+	for _, c := range j.cookies[j.env] {
+		if !c.expired(now) && c.matches(u) {
+			matching = append(matching, c)
+		}
+	}
+
+	slices.SortStableFunc(matching, func(a, b *Cookie) int {
+		return len(b.Path) - len(a.Path)
+	})
+
+	cookies := make([]*http.Cookie, 0, len(matching))
+	for _, c := range matching {
+		cookies = append(cookies, &http.Cookie{Name: c.Name, Value: c.Value})
+	}
+
+	return cookies
+}
+
+// List returns the unexpired cookies of the environment with the given name, ordered by domain, path and name.
+func (j *Jar) List(env string) []Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := j.now()
+
+	list := make([]Cookie, 0, len(j.cookies[env]))
+	for _, c := range j.cookies[env] {
+		if !c.expired(now) {
+			list = append(list, *c)
+		}
+	}
+
+	slices.SortFunc(list, func(a, b Cookie) int {
+		return cmpCookies(&a, &b)
+	})
+
+	return list
+}
+
+// Set stores c for the selected environment, replacing the cookie with the same domain, path and name.
+func (j *Jar) Set(c Cookie) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.store(&c)
+
+	return j.save()
+}
+
+// Delete removes the cookie with the same domain, path and name as c from the selected environment.
+func (j *Jar) Delete(c Cookie) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.cookies[j.env] = slices.DeleteFunc(j.cookies[j.env], func(existing *Cookie) bool {
+		return same(existing, &c)
+	})
+
+	return j.save()
+}
+
+// store adds c to the cookies of the selected environment, replacing the cookie it is the same as and dropping any
+// that have expired.
+func (j *Jar) store(c *Cookie) {
+	now := j.now()
+
+	j.cookies[j.env] = slices.DeleteFunc(j.cookies[j.env], func(existing *Cookie) bool {
+		return same(existing, c) || existing.expired(now)
+	})
+
+	if !c.expired(now) {
+		j.cookies[j.env] = append(j.cookies[j.env], c)
+	}
+}
+
+// save writes the cookies of the selected environment to its file.
+func (j *Jar) save() error {
+	if j.env == "" {
+		return nil
+	}
+
+	cookies := j.cookies[j.env]
+	if cookies == nil {
+		cookies = []*Cookie{}
+	}
+
+	body, err := json.MarshalIndent(cookies, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding cookies: %w", err)
+	}
+
+	if err := os.MkdirAll(j.dir, 0o700); err != nil {
+		return fmt.Errorf("creating cookies directory: %w", err)
+	}
+
+	// cookies often hold session credentials, so only the user can read them
+	if err := fileutil.WriteAtomic(j.path(j.env), body, 0o600); err != nil {
+		return fmt.Errorf("saving cookies of %s: %w", j.env, err)
+	}
+
+	return nil
+}
+
+// path returns the file the cookies of env are persisted to. The name is escaped, so a name containing separators or
+// ".." stays within dir.
+func (j *Jar) path(env string) string {
+	return filepath.Join(j.dir, url.PathEscape(env)+".json")
+}
+
+// same reports whether a and b are the same cookie, which is the case when their domain, path and name match.
+func same(a, b *Cookie) bool {
+	return a.Domain == b.Domain && a.Path == b.Path && a.Name == b.Name
+}
+
+func cmpCookies(a, b *Cookie) int {
+	if c := strings.Compare(a.Domain, b.Domain); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Path, b.Path); c != 0 {
+		return c
+	}
+
+	return strings.Compare(a.Name, b.Name)
+}
+
+// domainMatch reports whether host is domain or one of its subdomains.
+func domainMatch(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain) && net.ParseIP(host) == nil
+}
+
+// pathMatch reports whether a request to path gets the cookies of cookiePath.
+func pathMatch(path, cookiePath string) bool {
+	if path == "" {
+		path = "/"
+	}
+
+	if !strings.HasPrefix(path, cookiePath) {
+		return false
+	}
+
+	return len(path) == len(cookiePath) || strings.HasSuffix(cookiePath, "/") || path[len(cookiePath)] == '/'
+}
+
+// defaultPath is the path of a cookie set without one, the directory of the request path.
+func defaultPath(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		return "/"
+	}
+
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+
+	return path[:i]
+}
@@ -0,0 +1,182 @@
+package cookie_test
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/cookie"
+)
+
+func names(cookies []*http.Cookie) []string {
+	list := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		list = append(list, c.Name+"="+c.Value)
+	}
+
+	return list
+}
+
+func TestJar_Cookies(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	jar := cookie.New(t.TempDir(), cookie.WithClock(func() time.Time { return now }))
+	require.NoError(t, jar.Select("dev"))
+
+	origin, _ := url.Parse("https://api.example.com/v1/users")
+	jar.SetCookies(origin, []*http.Cookie{
+		{Name: "host", Value: "1"},
+		{Name: "domain", Value: "2", Domain: ".example.com", Path: "/"},
+		{Name: "v1", Value: "3", Path: "/v1"},
+		{Name: "secure", Value: "4", Path: "/", Secure: true},
+		{Name: "short", Value: "5", Path: "/", MaxAge: 60},
+		{Name: "expired", Value: "6", Path: "/", Expires: now.Add(-time.Hour)},
+		{Name: "other", Value: "7", Domain: "other.com"},
+	})
+
+	// cookies for public suffixes would be sent to every site sharing them
+	evil, _ := url.Parse("https://evil.co.uk/")
+	jar.SetCookies(evil, []*http.Cookie{
+		{Name: "suffix", Value: "8", Domain: "co.uk", Path: "/"},
+		{Name: "tld", Value: "9", Domain: ".uk", Path: "/"},
+		{Name: "evil", Value: "10", Domain: "evil.co.uk", Path: "/"},
+	})
+	pages, _ := url.Parse("https://github.io/")
+	jar.SetCookies(pages, []*http.Cookie{{Name: "pages", Value: "11", Domain: "github.io", Path: "/"}})
+
+	testCases := []struct {
+		name     string
+		url      string
+		expected []string
+	}{
+		{
+			name:     "Same origin",
+			url:      "https://api.example.com/v1/users/1",
+			expected: []string{"host=1", "v1=3", "domain=2", "secure=4", "short=5"},
+		},
+		{
+			name:     "Other path",
+			url:      "https://api.example.com/v10",
+			expected: []string{"domain=2", "secure=4", "short=5"},
+		},
+		{
+			name:     "Insecure",
+			url:      "http://api.example.com/v1",
+			expected: []string{"host=1", "v1=3", "domain=2", "short=5"},
+		},
+		{
+			name:     "Parent domain",
+			url:      "https://example.com/",
+			expected: []string{"domain=2"},
+		},
+		{
+			name:     "Other domain",
+			url:      "https://other.com/",
+			expected: []string{},
+		},
+		{
+			name:     "Public suffix",
+			url:      "https://victim.co.uk/",
+			expected: []string{},
+		},
+		{
+			name:     "Subdomain of a site under a public suffix",
+			url:      "https://www.evil.co.uk/",
+			expected: []string{"evil=10"},
+		},
+		{
+			name:     "Public suffix host",
+			url:      "https://github.io/",
+			expected: []string{"pages=11"},
+		},
+		{
+			name:     "Site under a public suffix host",
+			url:      "https://user.github.io/",
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				u, err := url.Parse(tc.url)
+				require.NoError(t, err)
+				assert.ElementsMatch(t, tc.expected, names(jar.Cookies(u)))
+			},
+		)
+	}
+
+	// cookies with longer paths are sent first
+	u, _ := url.Parse("https://api.example.com/v1/users")
+	assert.ElementsMatch(t, []string{"host=1", "v1=3"}, names(jar.Cookies(u))[:2])
+
+	now = now.Add(2 * time.Minute)
+	assert.NotContains(t, names(jar.Cookies(u)), "short=5")
+}
+
+func TestJar_Environments(t *testing.T) {
+	dir := t.TempDir()
+	u, _ := url.Parse("https://example.com/")
+
+	jar := cookie.New(dir)
+	require.NoError(t, jar.Select("dev"))
+	jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "dev"}})
+
+	require.NoError(t, jar.Select("prod"))
+	assert.Empty(t, jar.Cookies(u))
+	jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "prod"}, {Name: "theme", Value: "dark"}})
+
+	prod := jar.List("prod")
+	require.Len(t, prod, 2)
+	assert.Equal(t, cookie.Cookie{Name: "session", Value: "prod", Domain: "example.com", Path: "/", HostOnly: true}, prod[0])
+
+	prod[0].Value = "edited"
+	require.NoError(t, jar.Set(prod[0]))
+	require.NoError(t, jar.Delete(prod[1]))
+
+	info, err := os.Stat(filepath.Join(dir, "prod.json"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// cookies are loaded from disk by a new jar
+	restarted := cookie.New(dir)
+	require.NoError(t, restarted.Select("dev"))
+	assert.Equal(t, []string{"session=dev"}, names(restarted.Cookies(u)))
+	require.NoError(t, restarted.Select("prod"))
+	assert.Equal(t, []string{"session=edited"}, names(restarted.Cookies(u)))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600))
+	assert.ErrorContains(t, restarted.Select("broken"), "parsing cookies of broken")
+}
+
+func TestJar_EnvironmentPaths(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "data", "cookies")
+	u, _ := url.Parse("https://example.com/")
+
+	jar := cookie.New(dir)
+	for _, env := range []string{"../../escaped", "team/dev", `..\\windows`} {
+		require.NoError(t, jar.Select(env))
+		jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: env}})
+	}
+
+	// every file stays in the cookies directory, whatever the environment is named
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+	_, err = os.Stat(filepath.Join(root, "escaped.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	restarted := cookie.New(dir)
+	require.NoError(t, restarted.Select("team/dev"))
+	assert.Equal(t, []string{"session=team/dev"}, names(restarted.Cookies(u)))
+	require.NoError(t, restarted.Select("../../escaped"))
+	assert.Equal(t, []string{"session=../../escaped"}, names(restarted.Cookies(u)))
+}
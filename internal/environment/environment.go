@@ -24,11 +24,30 @@ import (
 	"path"
 
 	"sigs.k8s.io/yaml"
+
+	"github.com/cstaaben/go-rest/internal/fileutil"
+	"github.com/cstaaben/go-rest/internal/transport"
 )
 
+// BaseName is the name of the environment every other environment implicitly extends, if it exists.
+const BaseName = "base"
+
 type Environment struct {
-	Name      string         `json:"name"`
+	Name string `json:"name"`
+	// Extends is the name of the environment this one inherits variables from. If empty, the environment extends the
+	// base environment.
+	Extends   string         `json:"extends,omitempty"`
 	Variables map[string]any `json:"variables"`
+	// Transport overrides the connection settings of the configuration while the environment is active. Like
+	// variables, they are inherited from the environment this one extends.
+	Transport *transport.Settings `json:"transport,omitempty"`
+	// Path is the file the environment was loaded from.
+	Path string `json:"-"`
+	// Captured holds the values captured from responses while the environment is active. They are never saved, and
+	// take precedence over every other variable.
+	Captured map[string]any `json:"-"`
+
+	parent *Environment
 }
 
 func New(name string) *Environment {
@@ -50,19 +69,65 @@ func Load(filepath string) ([]*Environment, error) {
 			continue
 		}
 
-		env := new(Environment)
 		body, err := os.ReadFile(path.Join(filepath, entry.Name()))
 		if err != nil {
 			return nil, fmt.Errorf("reading file: %w", err)
 		}
 
-		err = yaml.UnmarshalStrict(body, env)
+		env, err := Parse(body)
 		if err != nil {
-			return nil, fmt.Errorf("parsing environment: %w", err)
+			return nil, err
 		}
+		env.Path = path.Join(filepath, entry.Name())
 
 		results = append(results, env)
 	}
 
+	if err = Link(results); err != nil {
+		return nil, err
+	}
+
 	return results, nil
 }
+
+// Parse parses the YAML definition of an environment. Unknown fields are not allowed.
+func Parse(body []byte) (*Environment, error) {
+	env := new(Environment)
+	if err := yaml.UnmarshalStrict(body, env); err != nil {
+		return nil, fmt.Errorf("parsing environment: %w", err)
+	}
+
+	return env, nil
+}
+
+// Save validates body and atomically writes it to the file the environment was loaded from. The environment it
+// extends has to be one of envs, the environments loaded alongside e, without creating a cycle. On success, the
+// environment is updated to match body.
+func (e *Environment) Save(body []byte, envs []*Environment) error {
+	parsed, err := Parse(body)
+	if err != nil {
+		return err
+	}
+
+	// the links are checked with the saved environment taking the place of e
+	candidates := make([]*Environment, 0, len(envs)+1)
+	for _, env := range envs {
+		if env != e {
+			candidates = append(candidates, env)
+		}
+	}
+	if _, err = resolveParents(append(candidates, parsed)); err != nil {
+		return err
+	}
+
+	if err = fileutil.WriteAtomic(e.Path, body, 0644); err != nil {
+		return fmt.Errorf("writing environment: %w", err)
+	}
+
+	e.Name = parsed.Name
+	e.Extends = parsed.Extends
+	e.Variables = parsed.Variables
+	e.Transport = parsed.Transport
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package environment_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/environment"
+	"github.com/cstaaben/go-rest/internal/transport"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name         string
+		body         string
+		expected     *environment.Environment
+		expectingErr bool
+	}{
+		{
+			name: "Valid environment",
+			body: "name: dev\nvariables:\n  host: localhost\n",
+			expected: &environment.Environment{
+				Name:      "dev",
+				Variables: map[string]any{"host": "localhost"},
+			},
+		},
+		{
+			name:         "Unknown field",
+			body:         "name: dev\nvars: {}\n",
+			expectingErr: true,
+		},
+		{
+			name:         "Invalid YAML",
+			body:         "name: [dev\n",
+			expectingErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				actual, err := environment.Parse([]byte(tc.body))
+				if tc.expectingErr {
+					assert.Error(t, err)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, actual)
+			},
+		)
+	}
+}
+
+func TestEnvironment_Save(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dev.yaml"), []byte("name: dev\nvariables:\n  a: 1\n"), 0644))
+
+	envs, err := environment.Load(dir)
+	require.NoError(t, err)
+	require.Len(t, envs, 1)
+
+	env := envs[0]
+	assert.Equal(t, filepath.Join(dir, "dev.yaml"), env.Path)
+
+	// invalid content is never written
+	assert.Error(t, env.Save([]byte("name: dev\nunknown: true\n"), envs))
+	assert.ErrorContains(t, env.Save([]byte("name: dev\nextends: missing\n"), envs), "unknown environment missing")
+	assert.ErrorContains(t, env.Save([]byte("name: dev\nextends: dev\n"), envs), "environment inheritance cycle")
+	assert.Equal(t, map[string]any{"a": float64(1)}, env.Variables)
+
+	body := "name: dev\nvariables:\n  a: 2\ntransport:\n  tls:\n    min_version: \"1.3\"\n"
+	require.NoError(t, env.Save([]byte(body), envs))
+	assert.Equal(t, map[string]any{"a": float64(2)}, env.Variables)
+	require.NotNil(t, env.Transport)
+	assert.Equal(t, "1.3", env.Transport.TLS.MinVersion)
+
+	written, err := os.ReadFile(env.Path)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(written))
+}
+
+func TestLoad_Inheritance(t *testing.T) {
+	testCases := []struct {
+		name         string
+		files        map[string]string
+		env          string
+		expected     map[string]any
+		expectedVals []environment.Value
+		expectingErr string
+	}{
+		{
+			name: "Implicit base",
+			files: map[string]string{
+				"base.yaml": "name: base\nvariables:\n  host: example.com\n  auth:\n    id: base\n    secret: s\n",
+				"dev.yaml":  "name: dev\nvariables:\n  host: dev.example.com\n  auth:\n    id: dev\n",
+			},
+			env: "dev",
+			expected: map[string]any{
+				"host": "dev.example.com",
+				"auth": map[string]any{"id": "dev", "secret": "s"},
+			},
+			expectedVals: []environment.Value{
+				{Path: "auth.id", Value: "dev", Source: "dev", Overrides: true},
+				{Path: "auth.secret", Value: "s", Source: "base"},
+				{Path: "host", Value: "dev.example.com", Source: "dev", Overrides: true},
+			},
+		},
+		{
+			name: "Explicit parent",
+			files: map[string]string{
+				"base.yaml":    "name: base\nvariables:\n  a: base\n  b: base\n",
+				"staging.yaml": "name: staging\nvariables:\n  b: staging\n  c: staging\n",
+				"prod.yaml":    "name: prod\nextends: staging\nvariables:\n  c: prod\n",
+			},
+			env:      "prod",
+			expected: map[string]any{"a": "base", "b": "staging", "c": "prod"},
+			expectedVals: []environment.Value{
+				{Path: "a", Value: "base", Source: "base"},
+				{Path: "b", Value: "staging", Source: "staging"},
+				{Path: "c", Value: "prod", Source: "prod", Overrides: true},
+			},
+		},
+		{
+			name: "Cycle",
+			files: map[string]string{
+				"a.yaml": "name: a\nextends: b\n",
+				"b.yaml": "name: b\nextends: c\n",
+				"c.yaml": "name: c\nextends: a\n",
+			},
+			expectingErr: "environment inheritance cycle",
+		},
+		{
+			name: "Unknown parent",
+			files: map[string]string{
+				"a.yaml": "name: a\nextends: missing\n",
+			},
+			expectingErr: "unknown environment missing",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				dir := t.TempDir()
+				for name, body := range tc.files {
+					require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(body), 0644))
+				}
+
+				envs, err := environment.Load(dir)
+				if tc.expectingErr != "" {
+					assert.ErrorContains(t, err, tc.expectingErr)
+					return
+				}
+				require.NoError(t, err)
+
+				for _, env := range envs {
+					if env.Name == tc.env {
+						assert.Equal(t, tc.expected, env.Resolved())
+						assert.Equal(t, tc.expectedVals, env.Values())
+						return
+					}
+				}
+
+				t.Fatalf("environment %s not loaded", tc.env)
+			},
+		)
+	}
+}
+
+func TestLink_Invalid(t *testing.T) {
+	base := &environment.Environment{Name: environment.BaseName, Variables: map[string]any{"a": "base"}}
+	dev := &environment.Environment{Name: "dev", Variables: map[string]any{"b": "dev"}}
+	prod := &environment.Environment{Name: "prod", Extends: "dev", Variables: map[string]any{"c": "prod"}}
+	envs := []*environment.Environment{base, dev, prod}
+	require.NoError(t, environment.Link(envs))
+
+	expected := map[string]any{"a": "base", "b": "dev", "c": "prod"}
+
+	dev.Extends = "prod"
+	assert.ErrorContains(t, environment.Link(envs), "environment inheritance cycle")
+	// the environments are still linked as they were, so resolving them terminates
+	assert.Equal(t, expected, prod.Resolved())
+	assert.Len(t, prod.Values(), 3)
+
+	dev.Extends = "missing"
+	assert.ErrorContains(t, environment.Link(envs), "unknown environment missing")
+	assert.Equal(t, expected, prod.Resolved())
+	assert.Same(t, base, dev.Parent())
+}
+
+func TestEnvironment_Capture(t *testing.T) {
+	base := &environment.Environment{Name: environment.BaseName, Variables: map[string]any{"token": "base", "host": "b"}}
+	dev := &environment.Environment{Name: "dev", Variables: map[string]any{"token": "dev"}}
+	require.NoError(t, environment.Link([]*environment.Environment{base, dev}))
+
+	base.Capture(map[string]any{"host": "ignored"})
+	dev.Capture(map[string]any{"token": "captured", "id": float64(1)})
+	dev.Capture(map[string]any{"id": float64(2)})
+
+	// only the captures of the environment itself are used
+	assert.Equal(t, map[string]any{"token": "captured", "host": "b", "id": float64(2)}, dev.Resolved())
+
+	dev.ClearCaptured()
+	assert.Equal(t, map[string]any{"token": "dev", "host": "b"}, dev.Resolved())
+}
+
+func TestEnvironment_ResolvedTransport(t *testing.T) {
+	base, err := environment.Parse([]byte("name: base\ntransport:\n  tls:\n    ca_files: [base.pem]\n    min_version: \"1.3\"\n"))
+	require.NoError(t, err)
+	dev, err := environment.Parse([]byte("name: dev\ntransport:\n  tls:\n    ca_files: [dev.pem]\n    insecure: false\n"))
+	require.NoError(t, err)
+	require.NoError(t, environment.Link([]*environment.Environment{base, dev}))
+
+	insecure := true
+	config := transport.Settings{TLS: transport.TLS{CAFiles: []string{"config.pem"}, Insecure: &insecure}}
+
+	resolved := dev.ResolvedTransport(config)
+	assert.Equal(t, []string{"config.pem", "base.pem", "dev.pem"}, resolved.TLS.CAFiles)
+	assert.Equal(t, "1.3", resolved.TLS.MinVersion)
+	require.NotNil(t, resolved.TLS.Insecure)
+	assert.False(t, *resolved.TLS.Insecure)
+}
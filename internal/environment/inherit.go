@@ -0,0 +1,227 @@
+/*
+ * go-rest - a TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https: //www.gnu.org/licenses/>.
+ */
+
+package environment
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cstaaben/go-rest/internal/transport"
+)
+
+// Value is a single resolved variable of an environment.
+type Value struct {
+	// Path is the dotted path of the variable, e.g. "auth.client_id".
+	Path  string
+	Value any
+	// Source is the name of the environment the value is defined in.
+	Source string
+	// Overrides is set when the value is defined in the environment itself and replaces an inherited value.
+	Overrides bool
+}
+
+// Link connects each environment in envs to the environment it extends. Environments without an explicit parent
+// extend the base environment, if there is one. An error is returned if a parent doesn't exist or if environments
+// extend each other in a cycle, in which case every environment keeps the parent it had before.
+func Link(envs []*Environment) error {
+	parents, err := resolveParents(envs)
+	if err != nil {
+		return err
+	}
+
+	for _, env := range envs {
+		env.parent = parents[env]
+	}
+
+	return nil
+}
+
+// resolveParents returns the environment each of envs extends, without linking them, so nothing changes unless all of
+// them are valid.
+func resolveParents(envs []*Environment) (map[*Environment]*Environment, error) {
+	byName := make(map[string]*Environment, len(envs))
+	for _, env := range envs {
+		byName[env.Name] = env
+	}
+
+	parents := make(map[*Environment]*Environment, len(envs))
+	for _, env := range envs {
+		switch {
+		case env.Extends != "":
+			parent, ok := byName[env.Extends]
+			if !ok {
+				return nil, fmt.Errorf("environment %s extends unknown environment %s", env.Name, env.Extends)
+			}
+			parents[env] = parent
+		case env.Name != BaseName:
+			parents[env] = byName[BaseName]
+		}
+	}
+
+	for _, env := range envs {
+		chain := []string{env.Name}
+		for p := parents[env]; p != nil; p = parents[p] {
+			if slices.Contains(chain, p.Name) {
+				return nil, fmt.Errorf("environment inheritance cycle: %s", strings.Join(append(chain, p.Name), " -> "))
+			}
+			chain = append(chain, p.Name)
+		}
+	}
+
+	return parents, nil
+}
+
+// Parent returns the environment e inherits variables from, if any.
+func (e *Environment) Parent() *Environment {
+	return e.parent
+}
+
+// ResolvedTransport returns the connection settings of e merged over those of every environment it inherits from, and
+// over base, the settings of the configuration.
+func (e *Environment) ResolvedTransport(base transport.Settings) transport.Settings {
+	if e.parent != nil {
+		base = e.parent.ResolvedTransport(base)
+	}
+
+	if e.Transport == nil {
+		return base
+	}
+
+	return base.Merge(*e.Transport)
+}
+
+// Resolved returns the variables of e merged with those of every environment it inherits from, with the values
+// captured in e on top. Values defined closer to e win, and nested maps are merged rather than replaced.
+func (e *Environment) Resolved() map[string]any {
+	return merge(e.inherited(), e.Captured)
+}
+
+// inherited returns the variables of e merged with those of every environment it inherits from.
+func (e *Environment) inherited() map[string]any {
+	if e.parent == nil {
+		return merge(nil, e.Variables)
+	}
+
+	return merge(e.parent.inherited(), e.Variables)
+}
+
+// Capture adds values to the captured variables of e, replacing any captured earlier with the same name.
+func (e *Environment) Capture(values map[string]any) {
+	if e.Captured == nil {
+		e.Captured = make(map[string]any, len(values))
+	}
+
+	for name, value := range values {
+		e.Captured[name] = value
+	}
+}
+
+// ClearCaptured removes every captured variable from e.
+func (e *Environment) ClearCaptured() {
+	e.Captured = nil
+}
+
+// Values lists every resolved variable of e, sorted by path, along with where each value comes from.
+func (e *Environment) Values() []Value {
+	var values []Value
+
+	var inherited []Value
+	if e.parent != nil {
+		inherited = e.parent.Values()
+	}
+
+	own := make(map[string]bool)
+	for _, v := range flatten("", e.Variables) {
+		own[v.Path] = true
+		values = append(values, Value{
+			Path:   v.Path,
+			Value:  v.Value,
+			Source: e.Name,
+			Overrides: slices.ContainsFunc(inherited, func(i Value) bool {
+				return i.Path == v.Path || strings.HasPrefix(i.Path, v.Path+".") || strings.HasPrefix(v.Path, i.Path+".")
+			}),
+		})
+	}
+
+	for _, v := range inherited {
+		if !own[v.Path] && !overridden(v.Path, own) {
+			values = append(values, Value{Path: v.Path, Value: v.Value, Source: v.Source})
+		}
+	}
+
+	slices.SortFunc(values, func(a, b Value) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return values
+}
+
+// overridden reports whether a value at path is replaced by one of the paths in own, either because a parent of path
+// is defined as a non-map value or because path is a map replaced by a non-map value.
+func overridden(path string, own map[string]bool) bool {
+	for p := range own {
+		if strings.HasPrefix(path, p+".") || strings.HasPrefix(p, path+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// flatten lists the leaf values of vars by their dotted path.
+func flatten(prefix string, vars map[string]any) []Value {
+	var values []Value
+	for key, value := range vars {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		if nested, ok := value.(map[string]any); ok && len(nested) > 0 {
+			values = append(values, flatten(path, nested)...)
+			continue
+		}
+
+		values = append(values, Value{Path: path, Value: value})
+	}
+
+	return values
+}
+
+// merge returns a copy of base with every value from override applied on top of it.
+func merge(base, override map[string]any) map[string]any {
+	result := make(map[string]any, len(base)+len(override))
+	for key, value := range base {
+		result[key] = value
+	}
+
+	for key, value := range override {
+		baseMap, baseIsMap := result[key].(map[string]any)
+		overrideMap, overrideIsMap := value.(map[string]any)
+		if baseIsMap && overrideIsMap {
+			result[key] = merge(baseMap, overrideMap)
+			continue
+		}
+
+		result[key] = value
+	}
+
+	return result
+}
@@ -0,0 +1,61 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Package fileutil contains helpers for working with files in the data directory.
+package fileutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteAtomic writes data to the file at name. The data is written to a temporary file in the same directory first,
+// which then replaces name, so a crash while writing never leaves a partially written file behind.
+func WriteAtomic(name string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+	// removing the temporary file fails once it has been renamed, which is fine
+	defer os.Remove(tmp.Name()) // nolint:errcheck
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close() // nolint:errcheck
+		return fmt.Errorf("writing temporary file: %w", err)
+	}
+
+	if err = tmp.Sync(); err != nil {
+		tmp.Close() // nolint:errcheck
+		return fmt.Errorf("syncing temporary file: %w", err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("closing temporary file: %w", err)
+	}
+
+	if err = os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("setting permissions: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("replacing file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package fileutil_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/fileutil"
+)
+
+func TestWriteAtomic(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "file.yaml")
+
+	require.NoError(t, os.WriteFile(name, []byte("old"), 0644))
+	require.NoError(t, fileutil.WriteAtomic(name, []byte("new"), 0600))
+
+	body, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(body))
+
+	info, err := os.Stat(name)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// no temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
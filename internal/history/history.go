@@ -0,0 +1,164 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Package history keeps the timing of the last runs of each request, and persists it to the data directory so runs
+// can be compared across sessions.
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cstaaben/go-rest/internal/fileutil"
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+// File is the name of the file in the data directory that the history is persisted to.
+const File = "history.json"
+
+// MaxRuns is how many runs of each request are kept.
+const MaxRuns = 20
+
+// Run is the outcome of sending a request once.
+type Run struct {
+	Status     string         `json:"status,omitempty"`
+	StatusCode int            `json:"status_code,omitempty"`
+	Timing     request.Timing `json:"timing"`
+}
+
+// History is the runs of each request, most recent last. Requests are identified by their group and name, e.g.
+// "shop/orders/create". Reading and writing the file may be slow, so it is only done by Load and Record, which are
+// meant to run in the background.
+type History struct {
+	path string
+
+	// saving is held while the history is written, so the file is written in the order runs are recorded.
+	saving sync.Mutex
+	// mu guards the runs.
+	mu     sync.Mutex
+	loaded bool
+	runs   map[string][]Run
+}
+
+// New creates a history persisted to the file at path.
+func New(path string) *History {
+	return &History{path: path, runs: make(map[string][]Run)}
+}
+
+// Key identifies r, which belongs to group, in the history. Requests that don't belong to a group have no key, since
+// their name may not be unique.
+func Key(group *request.Group, r *request.Request) string {
+	if group == nil {
+		return ""
+	}
+
+	return group.FullName() + "/" + r.Name
+}
+
+// Load reads the history from its file, unless it has been read already.
+func (h *History) Load() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.load()
+}
+
+// Last returns the most recent run of the request with the given key, or nil if it hasn't been run or the history
+// hasn't been loaded yet.
+func (h *History) Last(key string) *Run {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	runs := h.runs[key]
+	if len(runs) == 0 {
+		return nil
+	}
+
+	last := runs[len(runs)-1]
+	return &last
+}
+
+// Record adds a run of the request with the given key that received resp, and saves the history. Only the last MaxRuns
+// runs of each request are kept.
+func (h *History) Record(key string, resp *request.Response) error {
+	if key == "" || resp == nil {
+		return nil
+	}
+
+	h.saving.Lock()
+	defer h.saving.Unlock()
+
+	h.mu.Lock()
+	if err := h.load(); err != nil {
+		h.mu.Unlock()
+		return err
+	}
+
+	runs := append(h.runs[key], Run{Status: resp.Status, StatusCode: resp.StatusCode, Timing: resp.Timing})
+	if len(runs) > MaxRuns {
+		runs = runs[len(runs)-MaxRuns:]
+	}
+	h.runs[key] = runs
+
+	body, err := json.MarshalIndent(h.runs, "", "  ")
+	h.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("encoding history: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("creating data directory: %w", err)
+	}
+
+	if err := fileutil.WriteAtomic(h.path, body, 0644); err != nil {
+		return fmt.Errorf("saving history: %w", err)
+	}
+
+	return nil
+}
+
+// load reads the history from its file, unless it has been read already.
+func (h *History) load() error {
+	if h.loaded {
+		return nil
+	}
+
+	body, err := os.ReadFile(h.path)
+	if errors.Is(err, os.ErrNotExist) {
+		h.loaded = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading history: %w", err)
+	}
+
+	var runs map[string][]Run
+	if err := json.Unmarshal(body, &runs); err != nil {
+		return fmt.Errorf("parsing history: %w", err)
+	}
+	if runs != nil {
+		h.runs = runs
+	}
+	h.loaded = true
+
+	return nil
+}
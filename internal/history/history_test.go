@@ -0,0 +1,72 @@
+package history_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/history"
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", history.File)
+	h := history.New(path)
+
+	require.NoError(t, h.Load())
+	assert.Nil(t, h.Last("orders/create"))
+
+	for i := 1; i <= history.MaxRuns+5; i++ {
+		resp := &request.Response{
+			Status:     "201 Created",
+			StatusCode: 201,
+			Timing:     request.Timing{Wait: time.Duration(i) * time.Millisecond, Total: time.Duration(i) * time.Second},
+		}
+		require.NoError(t, h.Record("orders/create", resp))
+	}
+
+	// requests without a key aren't recorded
+	require.NoError(t, h.Record("", &request.Response{StatusCode: 200}))
+
+	// the history survives restarts, keeping only the most recent runs
+	reloaded := history.New(path)
+	assert.Nil(t, reloaded.Last("orders/create"), "not loaded yet")
+	require.NoError(t, reloaded.Load())
+	last := reloaded.Last("orders/create")
+	require.NotNil(t, last)
+	assert.Equal(t, 201, last.StatusCode)
+	assert.Equal(t, time.Duration(history.MaxRuns+5)*time.Millisecond, last.Timing.Wait)
+	assert.Equal(t, time.Duration(history.MaxRuns+5)*time.Second, last.Timing.Total)
+
+	body, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), fmt.Sprintf(`"total": %d`, 5*time.Second))
+	assert.Contains(t, string(body), fmt.Sprintf(`"total": %d`, 6*time.Second))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+}
+
+func TestHistory_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), history.File)
+	require.NoError(t, os.WriteFile(path, []byte("{invalid"), 0644))
+
+	h := history.New(path)
+	assert.ErrorContains(t, h.Load(), "parsing history")
+	// the file isn't replaced while it can't be read
+	assert.ErrorContains(t, h.Record("orders/create", &request.Response{StatusCode: 200}), "parsing history")
+}
+
+func TestKey(t *testing.T) {
+	g := request.NewGroup("orders")
+	r := &request.Request{Name: "create"}
+
+	assert.Equal(t, "orders/create", history.Key(g, r))
+	assert.Empty(t, history.Key(nil, r))
+}
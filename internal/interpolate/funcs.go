@@ -0,0 +1,241 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package interpolate
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FuncPrefix marks a placeholder that calls a function rather than referencing a variable, e.g. {{$uuid}}.
+const FuncPrefix = "$"
+
+// Func generates a value from its arguments. Random values must be drawn from i.Rand and the current time taken from
+// i.Now, so the result is reproducible when the interpolator is seeded.
+type Func func(i *Interpolator, args []string) (string, error)
+
+// Funcs is the registry of functions that can be called from placeholders, by name.
+var Funcs = map[string]Func{
+	"uuid":         uuidFunc,
+	"timestamp":    timestampFunc,
+	"isoTimestamp": isoTimestampFunc,
+	"randomInt":    randomIntFunc,
+	"base64":       base64Func,
+	"hmacSHA256":   hmacSHA256Func,
+	"env":          envFunc,
+}
+
+// BodyArg is the bare argument referencing the resolved request body, so headers can carry a signature of it, e.g.
+// {{$hmacSHA256 signing_key body}}. A variable with the same name takes precedence.
+const BodyArg = "body"
+
+// literalFuncs take bare words as they are written instead of as variables, since their arguments name something else.
+var literalFuncs = map[string]bool{
+	"env": true,
+}
+
+// call evaluates a function placeholder, e.g. "$randomInt 1 100". Quoted arguments and numbers are taken literally,
+// and anything else references a variable like a placeholder does, optionally with a leading dot, e.g. .user.
+func (r *replacer) call(expr string) (string, []string, error) {
+	fields, err := splitArgs(expr)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", expr, err)
+	}
+
+	name := strings.TrimPrefix(fields[0], FuncPrefix)
+	fn, ok := Funcs[name]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown function %s", fields[0])
+	}
+
+	var unresolved []string
+	args := make([]string, 0, len(fields)-1)
+	for _, field := range fields[1:] {
+		if strings.HasPrefix(field, `"`) {
+			args = append(args, field[1:len(field)-1])
+			continue
+		}
+		if _, err := strconv.ParseFloat(field, 64); err == nil || literalFuncs[name] && !strings.HasPrefix(field, ".") {
+			args = append(args, field)
+			continue
+		}
+
+		ref := strings.TrimPrefix(field, ".")
+		value, ok := r.argument(ref)
+		if !ok {
+			unresolved = append(unresolved, ref)
+			continue
+		}
+		args = append(args, format(value))
+	}
+
+	if len(unresolved) > 0 {
+		return "", unresolved, nil
+	}
+
+	result, err := fn(r.Interpolator, args)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", fields[0], err)
+	}
+
+	return result, nil, nil
+}
+
+// splitArgs splits expr on spaces, keeping double-quoted arguments together.
+func splitArgs(expr string) ([]string, error) {
+	var (
+		fields []string
+		sb     strings.Builder
+		quoted bool
+	)
+
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			sb.WriteRune(r)
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			if sb.Len() > 0 {
+				fields = append(fields, sb.String())
+				sb.Reset()
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+
+	if sb.Len() > 0 {
+		fields = append(fields, sb.String())
+	}
+
+	return fields, nil
+}
+
+func expectArgs(args []string, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("expected %d arguments, got %d", n, len(args))
+	}
+
+	return nil
+}
+
+func uuidFunc(i *Interpolator, args []string) (string, error) {
+	if err := expectArgs(args, 0); err != nil {
+		return "", err
+	}
+
+	var b [16]byte
+	for j := range b {
+		b[j] = byte(i.Rand.IntN(256))
+	}
+
+	// version 4, variant 10
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+func timestampFunc(i *Interpolator, args []string) (string, error) {
+	if err := expectArgs(args, 0); err != nil {
+		return "", err
+	}
+
+	return strconv.FormatInt(i.Now().Unix(), 10), nil
+}
+
+func isoTimestampFunc(i *Interpolator, args []string) (string, error) {
+	if err := expectArgs(args, 0); err != nil {
+		return "", err
+	}
+
+	return i.Now().UTC().Format(time.RFC3339), nil
+}
+
+func randomIntFunc(i *Interpolator, args []string) (string, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return "", err
+	}
+
+	low, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid minimum: %w", err)
+	}
+
+	high, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid maximum: %w", err)
+	}
+
+	if high < low {
+		return "", fmt.Errorf("maximum %d is less than minimum %d", high, low)
+	}
+
+	// both bounds are inclusive, and the number of values has to fit in an int64 to be drawn from
+	span := uint64(high) - uint64(low)
+	if span >= math.MaxInt64 {
+		return "", fmt.Errorf("range from %d to %d is too wide", low, high)
+	}
+
+	return strconv.FormatInt(low+i.Rand.Int64N(int64(span)+1), 10), nil
+}
+
+func base64Func(_ *Interpolator, args []string) (string, error) {
+	if err := expectArgs(args, 1); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString([]byte(args[0])), nil
+}
+
+func hmacSHA256Func(_ *Interpolator, args []string) (string, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, []byte(args[0]))
+	mac.Write([]byte(args[1])) // nolint:errcheck
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func envFunc(_ *Interpolator, args []string) (string, error) {
+	if err := expectArgs(args, 1); err != nil {
+		return "", err
+	}
+
+	value, ok := os.LookupEnv(args[0])
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", args[0])
+	}
+
+	return value, nil
+}
@@ -0,0 +1,161 @@
+package interpolate_test
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/interpolate"
+)
+
+func TestInterpolator_Funcs(t *testing.T) {
+	t.Setenv("GO_REST_TEST_VAR", "from env")
+
+	testCases := []struct {
+		name         string
+		input        string
+		expected     string
+		expectingErr string
+	}{
+		{
+			name:     "Timestamp",
+			input:    "{{$timestamp}}",
+			expected: "1700000000",
+		},
+		{
+			name:     "ISO timestamp",
+			input:    "{{ $isoTimestamp }}",
+			expected: "2023-11-14T22:13:20Z",
+		},
+		{
+			name:     "Base64 of a variable",
+			input:    "Basic {{$base64 .auth.client_id}}",
+			expected: "Basic YWJjMTIz",
+		},
+		{
+			name:     "Base64 of a bare variable",
+			input:    "{{$base64 auth.client_id}}",
+			expected: "YWJjMTIz",
+		},
+		{
+			name:     "HMAC of variables",
+			input:    "{{$hmacSHA256 hmac.key hmac.message}}",
+			expected: "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		},
+		{
+			name:     "Base64 of a quoted literal",
+			input:    `{{$base64 "user:pass word"}}`,
+			expected: "dXNlcjpwYXNzIHdvcmQ=",
+		},
+		{
+			name:     "HMAC",
+			input:    `{{$hmacSHA256 "key" "The quick brown fox jumps over the lazy dog"}}`,
+			expected: "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		},
+		{
+			name:     "Environment variable",
+			input:    "{{$env GO_REST_TEST_VAR}}",
+			expected: "from env",
+		},
+		{
+			name:         "Unset environment variable",
+			input:        "{{$env GO_REST_TEST_UNSET}}",
+			expectingErr: "environment variable GO_REST_TEST_UNSET is not set",
+		},
+		{
+			name:         "Unknown function",
+			input:        "{{$nope}}",
+			expectingErr: "unknown function $nope",
+		},
+		{
+			name:         "Wrong number of arguments",
+			input:        "{{$randomInt 1}}",
+			expectingErr: "expected 2 arguments, got 1",
+		},
+		{
+			name:     "Random integer between equal bounds",
+			input:    "{{$randomInt -9223372036854775808 -9223372036854775808}}",
+			expected: "-9223372036854775808",
+		},
+		{
+			name:         "Random integer range too wide",
+			input:        "{{$randomInt 0 9223372036854775807}}",
+			expectingErr: "range from 0 to 9223372036854775807 is too wide",
+		},
+		{
+			name:         "Random integer range too wide to subtract",
+			input:        "{{$randomInt -9223372036854775808 9223372036854775807}}",
+			expectingErr: "is too wide",
+		},
+		{
+			name:         "Unresolved argument",
+			input:        "{{$base64 .missing}}",
+			expectingErr: "unresolved variables: missing",
+		},
+		{
+			name:         "Unresolved bare argument",
+			input:        "{{$hmacSHA256 missing body}}",
+			expectingErr: "unresolved variables: missing, body",
+		},
+		{
+			name:         "Unterminated quote",
+			input:        `{{$base64 "abc}}`,
+			expectingErr: "unterminated quote",
+		},
+	}
+
+	now := func() time.Time { return time.Unix(1700000000, 0) }
+	funcVars := map[string]any{
+		"hmac": map[string]any{"key": "key", "message": "The quick brown fox jumps over the lazy dog"},
+	}
+	for name, value := range vars {
+		funcVars[name] = value
+	}
+	i := interpolate.New(funcVars, interpolate.WithClock(now))
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				actual, err := i.String(tc.input)
+				if tc.expectingErr != "" {
+					assert.ErrorContains(t, err, tc.expectingErr)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, actual)
+			},
+		)
+	}
+}
+
+func TestInterpolator_FuncsSeeded(t *testing.T) {
+	input := "{{$uuid}} {{$randomInt 1 100}} {{$uuid}}"
+
+	render := func(seed uint64) string {
+		r := rand.New(rand.NewPCG(seed, seed))
+		actual, err := interpolate.New(vars, interpolate.WithRand(r)).String(input)
+		require.NoError(t, err)
+		return actual
+	}
+
+	first := render(42)
+	assert.Equal(t, first, render(42))
+	assert.NotEqual(t, first, render(43))
+	assert.Regexp(
+		t,
+		`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12} ([1-9][0-9]?|100) [0-9a-f-]{36}$`,
+		first,
+	)
+
+	for range 100 {
+		actual, err := interpolate.New(nil).String("{{$randomInt 3 4}}")
+		require.NoError(t, err)
+		assert.Contains(t, []string{"3", "4"}, actual)
+	}
+}
@@ -0,0 +1,279 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Package interpolate replaces {{placeholders}} in request data with the values of variables.
+package interpolate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+// SecretPrefix marks a placeholder that references a secret rather than a variable, e.g. {{secret:github_token}}.
+const SecretPrefix = "secret:"
+
+var placeholder = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+
+// UnresolvedError is returned when placeholders reference variables that are not defined.
+type UnresolvedError struct {
+	Names []string
+}
+
+func (e *UnresolvedError) Error() string {
+	return "unresolved variables: " + strings.Join(e.Names, ", ")
+}
+
+// SecretFunc returns the secret with the given name.
+type SecretFunc func(name string) (string, bool)
+
+type Option func(*Interpolator)
+
+// WithSecrets resolves secret placeholders with fn.
+func WithSecrets(fn SecretFunc) Option {
+	return func(i *Interpolator) {
+		i.Secrets = fn
+	}
+}
+
+// WithRand draws the random values generated by functions such as $uuid from r. Sharing a seeded source between
+// interpolators makes a sequence of requests reproducible.
+func WithRand(r *rand.Rand) Option {
+	return func(i *Interpolator) {
+		i.Rand = r
+	}
+}
+
+// WithClock takes the current time used by functions such as $timestamp from now.
+func WithClock(now func() time.Time) Option {
+	return func(i *Interpolator) {
+		i.Now = now
+	}
+}
+
+// Interpolator resolves placeholders against a set of variables.
+type Interpolator struct {
+	Variables map[string]any
+	// Secrets resolves secret placeholders. If nil, secret placeholders can't be resolved.
+	Secrets SecretFunc
+	// Rand is the source of random values for functions.
+	Rand *rand.Rand
+	// Now returns the current time for functions.
+	Now func() time.Time
+}
+
+// New creates an Interpolator for vars and applies the provided options.
+func New(vars map[string]any, opts ...Option) *Interpolator {
+	i := &Interpolator{
+		Variables: vars,
+		Rand:      rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		Now:       time.Now,
+	}
+
+	for _, optFunc := range opts {
+		optFunc(i)
+	}
+
+	return i
+}
+
+// Data returns a copy of d with every placeholder in its URL, header values, body and credentials resolved. If any
+// placeholder can't be resolved, an *UnresolvedError listing all of them is returned.
+func (i *Interpolator) Data(d *request.Data) (*request.Data, error) {
+	r := &replacer{Interpolator: i}
+
+	// the body is resolved first, so the other fields can pass it to functions
+	c := d.Clone()
+	c.Body = r.replace(c.Body)
+	r.body = &c.Body
+	c.URL = r.replace(c.URL)
+
+	for name, values := range c.Headers {
+		for j := range values {
+			values[j] = r.replace(values[j])
+		}
+		c.Headers[name] = values
+	}
+
+	if c.Auth != nil {
+		for _, field := range c.Auth.Fields() {
+			*field = r.replace(*field)
+		}
+	}
+
+	if err := r.err(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// String resolves every placeholder in s.
+func (i *Interpolator) String(s string) (string, error) {
+	r := &replacer{Interpolator: i}
+
+	result := r.replace(s)
+	if err := r.err(); err != nil {
+		return "", err
+	}
+
+	return result, nil
+}
+
+// replacer collects the problems found while resolving placeholders, so they can all be reported at once.
+type replacer struct {
+	*Interpolator
+	// body is the resolved request body, once it is known.
+	body       *string
+	unresolved []string
+	errs       []error
+}
+
+// replace resolves the placeholders in s, leaving any that can't be resolved in place.
+func (r *replacer) replace(s string) string {
+	return placeholder.ReplaceAllStringFunc(s, func(match string) string {
+		name := placeholder.FindStringSubmatch(match)[1]
+
+		if strings.HasPrefix(name, FuncPrefix) {
+			value, unresolved, err := r.call(name)
+			if err != nil {
+				r.errs = append(r.errs, err)
+				return match
+			}
+			if len(unresolved) > 0 {
+				r.addUnresolved(unresolved...)
+				return match
+			}
+
+			return value
+		}
+
+		value, ok := r.lookup(name)
+		if !ok {
+			r.addUnresolved(name)
+			return match
+		}
+
+		return format(value)
+	})
+}
+
+func (r *replacer) addUnresolved(names ...string) {
+	for _, name := range names {
+		if !slices.Contains(r.unresolved, name) {
+			r.unresolved = append(r.unresolved, name)
+		}
+	}
+}
+
+// err returns an *UnresolvedError for the unresolved variables, joined with any errors from calling functions.
+func (r *replacer) err() error {
+	errs := r.errs
+	if len(r.unresolved) > 0 {
+		errs = append([]error{&UnresolvedError{Names: r.unresolved}}, errs...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// argument resolves a bare argument of a function, which is a variable or the request body.
+func (r *replacer) argument(name string) (any, bool) {
+	if value, ok := r.lookup(name); ok {
+		return value, true
+	}
+	if name == BodyArg && r.body != nil {
+		return *r.body, true
+	}
+
+	return nil, false
+}
+
+func (i *Interpolator) lookup(name string) (any, bool) {
+	if secret, ok := strings.CutPrefix(name, SecretPrefix); ok {
+		if i.Secrets == nil {
+			return nil, false
+		}
+
+		return i.Secrets(secret)
+	}
+
+	return Lookup(i.Variables, name)
+}
+
+// Lookup finds the value at path in vars. Nested values are reached with dotted paths, e.g. "auth.client_id", and list
+// elements by their index, e.g. "users.0.name".
+func Lookup(vars map[string]any, path string) (any, bool) {
+	if path == "" {
+		return nil, false
+	}
+
+	// a key containing dots takes precedence over a nested path
+	if value, ok := vars[path]; ok {
+		return value, true
+	}
+
+	var current any = vars
+	for _, part := range strings.Split(path, ".") {
+		switch v := current.(type) {
+		case map[string]any:
+			value, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []any:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			current = v[idx]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// format converts a variable value to the string it is replaced with.
+func format(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any, []any:
+		body, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(body)
+	default:
+		return fmt.Sprint(v)
+	}
+}
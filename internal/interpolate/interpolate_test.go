@@ -0,0 +1,141 @@
+package interpolate_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/interpolate"
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+var vars = map[string]any{
+	"host":    "api.example.com",
+	"port":    float64(8080),
+	"debug":   true,
+	"dot.key": "dotted",
+	"auth": map[string]any{
+		"client_id": "abc123",
+		"scopes":    []any{"read", "write"},
+	},
+}
+
+func TestInterpolator_String(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         string
+		expected      string
+		expectedNames []string
+	}{
+		{
+			name:     "No placeholders",
+			input:    "https://example.com",
+			expected: "https://example.com",
+		},
+		{
+			name:     "Variables",
+			input:    "https://{{host}}:{{ port }}/?debug={{debug}}",
+			expected: "https://api.example.com:8080/?debug=true",
+		},
+		{
+			name:     "Nested variables",
+			input:    "{{auth.client_id}} {{auth.scopes.1}}",
+			expected: "abc123 write",
+		},
+		{
+			name:     "Key containing dots",
+			input:    "{{dot.key}}",
+			expected: "dotted",
+		},
+		{
+			name:     "Object value",
+			input:    "{{auth.scopes}}",
+			expected: `["read","write"]`,
+		},
+		{
+			name:     "Secret",
+			input:    "Bearer {{secret:token}}",
+			expected: "Bearer s3cr3t",
+		},
+		{
+			name:          "Unresolved secret",
+			input:         "{{secret:missing}}",
+			expectedNames: []string{"secret:missing"},
+		},
+		{
+			name:          "Unresolved variables",
+			input:         "{{missing}} {{auth.secret}} {{missing}} {{auth.scopes.5}}",
+			expectedNames: []string{"missing", "auth.secret", "auth.scopes.5"},
+		},
+	}
+
+	secrets := func(name string) (string, bool) {
+		if name == "token" {
+			return "s3cr3t", true
+		}
+		return "", false
+	}
+
+	i := interpolate.New(vars, interpolate.WithSecrets(secrets))
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				actual, err := i.String(tc.input)
+				if tc.expectedNames != nil {
+					var unresolved *interpolate.UnresolvedError
+					require.ErrorAs(t, err, &unresolved)
+					assert.Equal(t, tc.expectedNames, unresolved.Names)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, actual)
+			},
+		)
+	}
+}
+
+func TestInterpolator_Data(t *testing.T) {
+	d := &request.Data{
+		URL:     "https://{{host}}/clients/{{auth.client_id}}",
+		Method:  "POST",
+		Headers: map[string][]string{"X-Client": {"{{auth.client_id}}"}},
+		Body:    `{"debug": {{debug}}}`,
+		Auth:    &request.Auth{Type: request.AuthBearer, Token: "{{auth.client_id}}"},
+	}
+
+	actual, err := interpolate.New(vars).Data(d)
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/clients/abc123", actual.URL)
+	assert.Equal(t, []string{"abc123"}, actual.Headers["X-Client"])
+	assert.Equal(t, `{"debug": true}`, actual.Body)
+	assert.Equal(t, "abc123", actual.Auth.Token)
+
+	// the original data is left untouched
+	assert.Equal(t, []string{"{{auth.client_id}}"}, d.Headers["X-Client"])
+	assert.Equal(t, "{{auth.client_id}}", d.Auth.Token)
+
+	// functions in the other fields can sign the resolved body
+	signed := &request.Data{
+		Headers: map[string][]string{"X-Signature": {`{{$hmacSHA256 "key" body}}`}},
+		Body:    "The quick brown fox jumps over the {{dog}}",
+	}
+	actual, err = interpolate.New(map[string]any{"dog": "lazy dog"}).Data(signed)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]string{"f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		actual.Headers["X-Signature"],
+	)
+
+	d.Headers["X-Other"] = []string{"{{other}}"}
+	d.Body = "{{body}}"
+	_, err = interpolate.New(vars).Data(d)
+
+	var unresolved *interpolate.UnresolvedError
+	require.ErrorAs(t, err, &unresolved)
+	assert.ElementsMatch(t, []string{"body", "other"}, unresolved.Names)
+}
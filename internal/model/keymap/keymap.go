@@ -33,8 +33,9 @@ var (
 			key.WithHelp(tea.KeyCtrlQ.String(), "Quit"),
 		),
 		Send: key.NewBinding(
-			key.WithKeys("Ctrl+Enter"),
-			key.WithHelp("Ctrl+Enter", "Send request"),
+			// most terminals can't distinguish ctrl+enter from enter, so ctrl+r is accepted as well
+			key.WithKeys("ctrl+enter", tea.KeyCtrlR.String()),
+			key.WithHelp(tea.KeyCtrlR.String(), "Send request"),
 		),
 		NextPane: key.NewBinding(
 			key.WithKeys(tea.KeyTab.String()),
@@ -44,6 +45,26 @@ var (
 			key.WithKeys(tea.KeyShiftTab.String()),
 			key.WithHelp(tea.KeyShiftTab.String(), "Previous Pane"),
 		),
+		Save: key.NewBinding(
+			key.WithKeys(tea.KeyCtrlS.String()),
+			key.WithHelp(tea.KeyCtrlS.String(), "Save"),
+		),
+		SaveAs: key.NewBinding(
+			key.WithKeys("alt+s"),
+			key.WithHelp("alt+s", "Save As"),
+		),
+		SwitchView: key.NewBinding(
+			key.WithKeys(tea.KeyCtrlE.String()),
+			key.WithHelp(tea.KeyCtrlE.String(), "Switch View"),
+		),
+		NextTab: key.NewBinding(
+			key.WithKeys(tea.KeyCtrlRight.String()),
+			key.WithHelp(tea.KeyCtrlRight.String(), "Next Tab"),
+		),
+		PreviousTab: key.NewBinding(
+			key.WithKeys(tea.KeyCtrlLeft.String()),
+			key.WithHelp(tea.KeyCtrlLeft.String(), "Previous Tab"),
+		),
 	}
 )
 
@@ -51,17 +72,25 @@ var (
 type KeyMap struct {
 	Quit key.Binding
 	Send key.Binding
+	Save key.Binding
+	// SaveAs saves a copy of the request under a new name.
+	SaveAs key.Binding
 	// Delete key.Binding
 	// Help         key.Binding
 	NextPane     key.Binding
 	PreviousPane key.Binding
+	// SwitchView switches between the client and environment views.
+	SwitchView key.Binding
+	// NextTab and PreviousTab switch between the tabs of the focused pane.
+	NextTab     key.Binding
+	PreviousTab key.Binding
 }
 
 // ShortHelp returns a slice of bindings to be displayed in the short
 // version of the help. The help bubble will render help in the order in
 // which the help items are returned here.
 func (k *KeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.NextPane, k.PreviousPane, k.Send, k.Quit}
+	return []key.Binding{k.NextPane, k.PreviousPane, k.SwitchView, k.Send, k.Quit}
 }
 
 // FullHelp returns an extended group of help items, grouped by columns.
@@ -69,7 +98,8 @@ func (k *KeyMap) ShortHelp() []key.Binding {
 // items are returned here.
 func (k *KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.NextPane, k.PreviousPane, k.Send},
+		{k.NextPane, k.PreviousPane, k.SwitchView, k.Send},
+		{k.NextTab, k.PreviousTab, k.Save, k.SaveAs},
 		{k.Quit},
 	}
 }
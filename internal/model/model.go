@@ -20,16 +20,32 @@
 package model
 
 import (
+	"context"
 	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"path/filepath"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"log/slog"
 
+	"github.com/cstaaben/go-rest/internal/client"
 	"github.com/cstaaben/go-rest/internal/config"
+	"github.com/cstaaben/go-rest/internal/cookie"
+	"github.com/cstaaben/go-rest/internal/environment"
+	"github.com/cstaaben/go-rest/internal/history"
+	"github.com/cstaaben/go-rest/internal/interpolate"
 	"github.com/cstaaben/go-rest/internal/model/keymap"
 	"github.com/cstaaben/go-rest/internal/model/target"
+	"github.com/cstaaben/go-rest/internal/oauth"
+	"github.com/cstaaben/go-rest/internal/request"
+	"github.com/cstaaben/go-rest/internal/secrets"
+	"github.com/cstaaben/go-rest/internal/ui/cookies"
 	"github.com/cstaaben/go-rest/internal/ui/editor"
 	"github.com/cstaaben/go-rest/internal/ui/enveditor"
 	"github.com/cstaaben/go-rest/internal/ui/environments"
@@ -37,24 +53,101 @@ import (
 	"github.com/cstaaben/go-rest/internal/ui/notification"
 	"github.com/cstaaben/go-rest/internal/ui/requests"
 	"github.com/cstaaben/go-rest/internal/ui/response"
+	"github.com/cstaaben/go-rest/internal/ui/styles"
 )
 
 var _ tea.Model = (*Model)(nil)
 
-func New() *Model {
+type Option func(*Model)
+
+// WithSeed seeds the random values generated when resolving functions such as {{$uuid}}, so the values in a sequence of
+// requests are reproducible.
+func WithSeed(seed uint64) Option {
+	return func(m *Model) {
+		m.rand = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
+// WithClock takes the current time used when resolving functions such as {{$timestamp}} from now, so it can be pinned
+// along with the seed.
+func WithClock(now func() time.Time) Option {
+	return func(m *Model) {
+		m.now = now
+	}
+}
+
+// New creates the model for the TUI. Requests sent from the TUI are canceled when ctx is done. Secret variables are
+// resolved with vault, which is nil if the vault is locked.
+func New(ctx context.Context, vault *secrets.Vault, opts ...Option) *Model {
+	jar := cookie.New(filepath.Join(config.DataDir(), cookie.Dir))
+	runs := history.New(filepath.Join(config.DataDir(), history.File))
+	c := client.New(client.WithCookies(jar))
+	// tokens are requested with the same CA bundles, client certificates and proxy as requests
+	tokens := oauth.New(oauth.WithHTTPClient(&http.Client{Transport: c.Transport()}))
+	c.Tokens = tokens
+
 	m := &Model{
+		ctx:          ctx,
+		vault:        vault,
+		rand:         rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		now:          time.Now,
+		latest:       make(map[*request.Request]int),
+		tokens:       tokens,
+		jar:          jar,
+		history:      runs,
+		Client:       c,
 		Keys:         keymap.Default,
 		Help:         help.New(help.WithKeyMap(keymap.Default)),
-		Environments: environments.New(config.DataDir()),
+		Environments: environments.New(config.DataDir(), config.DefaultEnv()),
 		Requests:     requests.New(config.DataDir()),
+		EnvEditor:    enveditor.New(),
 		Editor:       editor.New(),
 		Response:     response.New(),
+		Cookies:      cookies.New(jar),
+	}
+
+	m.Environments.Tokens = tokens
+	m.configureErr = m.Client.Configure(config.Transport())
+
+	for _, optFunc := range opts {
+		optFunc(m)
 	}
 
 	return m
 }
 
 type Model struct {
+	ctx context.Context
+	// sent is the number of requests that have been sent, used to identify each of them.
+	sent int
+	// latest is the ID of the last time each request was sent, so a response arriving after that of a later send
+	// doesn't replace it.
+	latest map[*request.Request]int
+	// confirm is the action waiting to be confirmed by the user, if any.
+	confirm *confirmation
+	// prompt is the action waiting for the user to enter a value, if any.
+	prompt *prompt
+	vault  *secrets.Vault
+	// chain is the requests waiting to be sent once the request identified by chainID has succeeded, ending with the
+	// request the user sent after its prerequisites.
+	chain   []*request.Request
+	chainID int
+	// rand is shared by every request sent, so a seeded sequence of requests is reproducible.
+	rand *rand.Rand
+	// now returns the current time for functions.
+	now func() time.Time
+	// tokens caches the OAuth 2.0 access tokens of each environment.
+	tokens *oauth.Tokens
+	// jar keeps the cookies of each environment.
+	jar *cookie.Jar
+	// history keeps the timing of past runs of each request, to compare them across sessions.
+	history *history.History
+	// configureErr is the error from configuring the client at startup, reported once the TUI is running.
+	configureErr error
+
+	Client *client.Client
+	// Environment is the environment whose variables are used when sending requests.
+	Environment   *environment.Environment
 	CurrentTarget target.Target
 	CurrentView   target.View
 	Keys          *keymap.KeyMap
@@ -65,6 +158,42 @@ type Model struct {
 	Requests     *requests.Model
 	Editor       *editor.Model
 	Response     *response.Model
+	Cookies      *cookies.Model
+	// Notification is the most recent notification, displayed until the next key press.
+	Notification *notification.Notification
+}
+
+// configured reports an error from configuring the connections of the client. Requests keep being sent with the
+// previous settings.
+func (m *Model) configured(err error) tea.Cmd {
+	if err == nil {
+		return nil
+	}
+
+	return notification.Notify(notification.Error, "configuring connections: "+err.Error())
+}
+
+// confirmation is an action that is only performed once the user confirms it.
+type confirmation struct {
+	prompt string
+	action func() tea.Cmd
+}
+
+// prompt is an action that is performed with a value entered by the user.
+type prompt struct {
+	input  textinput.Model
+	action func(value string) tea.Cmd
+}
+
+// ask prompts the user for a value, starting with value, then performs action with it.
+func (m *Model) ask(label, value string, action func(value string) tea.Cmd) tea.Cmd {
+	input := textinput.New()
+	input.Prompt = label + ": "
+	input.SetValue(value)
+	input.CursorEnd()
+
+	m.prompt = &prompt{input: input, action: action}
+	return m.prompt.input.Focus()
 }
 
 // Init is the first function that will be called. It returns an optional
@@ -73,6 +202,10 @@ func (m *Model) Init() tea.Cmd {
 	return tea.Batch(
 		tea.SetWindowTitle("go-rest"),
 		target.ChangeFocus(target.ClientView, target.RequestsTarget, target.ClientView, target.ResponseTarget),
+		m.Environments.Init(),
+		m.Requests.Init(),
+		m.configured(m.configureErr),
+		m.loadHistory(),
 	)
 }
 
@@ -92,10 +225,96 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		commands = append(commands, m.updateAllComponents(msg)...)
 	case tea.KeyMsg:
 		commands = append(commands, m.handleKey(msg))
+	case response.ResultMsg:
+		if msg.Err == nil && msg.ID == m.latest[msg.Request] {
+			// the response is kept with the request, so secrets sent with it must not be
+			msg.Response.RawRequest = secrets.Mask(msg.Response.RawRequest)
+			msg.Request.Data.Response = msg.Response
+			commands = append(commands, m.capture(msg.Request, msg.Response), m.record(msg.Request, msg.Response))
+		}
+		commands = append(commands, m.continueChain(msg))
+
+		var cmd tea.Cmd
+		m.Response, cmd = m.Response.Update(msg)
+		commands = append(commands, cmd)
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.Response, cmd = m.Response.Update(msg)
+		commands = append(commands, cmd)
+	case environments.LoadedMsg:
+		var cmd, envEditorCmd tea.Cmd
+		m.Environments, cmd = m.Environments.Update(msg)
+		m.EnvEditor, envEditorCmd = m.EnvEditor.Update(msg)
+		commands = append(commands, cmd, envEditorCmd)
+	case environments.SelectMsg:
+		env := msg.Environment
+		if m.EnvEditor.Dirty() && m.EnvEditor.Environment != env {
+			m.confirm = &confirmation{
+				prompt: fmt.Sprintf("Discard unsaved changes to %s?", m.EnvEditor.Environment.Name),
+				action: func() tea.Cmd { return m.Environments.Select(env) },
+			}
+			break
+		}
+
+		commands = append(commands, m.Environments.Select(env))
+	case environments.ChangedMsg:
+		m.Environment = msg.Environment
+		m.tokens.Select(msg.Environment.Name)
+		slog.Debug("environment changed", slog.String("name", msg.Environment.Name))
+
+		if err := m.jar.Select(msg.Environment.Name); err != nil {
+			commands = append(commands, notification.Notify(notification.Error, err.Error()))
+		}
+		commands = append(commands, m.configured(m.Client.Configure(msg.Environment.ResolvedTransport(config.Transport()))))
+
+		var editorCmd, envEditorCmd, cookiesCmd tea.Cmd
+		m.Editor, editorCmd = m.Editor.Update(msg)
+		m.EnvEditor, envEditorCmd = m.EnvEditor.Update(msg)
+		m.Cookies, cookiesCmd = m.Cookies.Update(msg)
+		commands = append(commands, editorCmd, envEditorCmd, cookiesCmd)
+	case enveditor.SavedMsg:
+		commands = append(commands, m.Environments.Relink())
+	case requests.LoadedMsg:
+		var cmd tea.Cmd
+		m.Requests, cmd = m.Requests.Update(msg)
+		commands = append(commands, cmd)
+	case requests.SelectedMsg:
+		m.Editor.Load(msg.Request, m.Requests.Group(msg.Request))
+	case requests.RenameMsg:
+		r := msg.Request
+		commands = append(commands, m.ask("Rename", r.Name, func(name string) tea.Cmd {
+			return m.Requests.Rename(r, name)
+		}))
+	case requests.DeleteMsg:
+		r := msg.Request
+		m.confirm = &confirmation{
+			prompt: fmt.Sprintf("Delete request %s?", r.Name),
+			action: func() tea.Cmd {
+				if m.Editor.CurrentRequest == r {
+					m.Editor.Load(r.Clone(), nil)
+				}
+				return m.Requests.Delete(r)
+			},
+		}
+	case cookies.EditMsg:
+		c := msg.Cookie
+		commands = append(commands, m.ask("Value of "+c.Name, c.Value, func(value string) tea.Cmd {
+			c.Value = value
+			return m.Cookies.Set(c)
+		}))
+	case cookies.DeleteMsg:
+		c := msg.Cookie
+		m.confirm = &confirmation{
+			prompt: fmt.Sprintf("Delete cookie %s of %s?", c.Name, c.Domain),
+			action: func() tea.Cmd {
+				return m.Cookies.Delete(c)
+			},
+		}
 	case notification.Notification:
-		panic("TODO: handle notification") // TODO: display notification popup
+		m.Notification = &msg
 	case error:
-		panic("need to handle error: " + msg.Error())
+		slog.Error("unhandled error", slog.Any("error", msg))
+		m.Notification = &notification.Notification{Message: msg.Error(), Level: notification.Error}
 	}
 
 	return m, tea.Batch(commands...)
@@ -120,6 +339,9 @@ func (m *Model) updateAllComponents(msg tea.WindowSizeMsg) []tea.Cmd {
 	var respCmd tea.Cmd
 	m.Response, respCmd = m.Response.Update(msg)
 
+	var cookiesCmd tea.Cmd
+	m.Cookies, cookiesCmd = m.Cookies.Update(msg)
+
 	return []tea.Cmd{
 		helpCmd,
 		envCmd,
@@ -127,6 +349,7 @@ func (m *Model) updateAllComponents(msg tea.WindowSizeMsg) []tea.Cmd {
 		reqCmd,
 		editorCmd,
 		respCmd,
+		cookiesCmd,
 	}
 }
 
@@ -173,6 +396,8 @@ func (m *Model) updateComponent(view target.View, t target.Target, msg tea.Msg)
 		case target.EnvEditorTarget:
 			m.EnvEditor, targetCmd = m.EnvEditor.Update(msg)
 		}
+	case target.CookiesView:
+		m.Cookies, targetCmd = m.Cookies.Update(msg)
 	}
 
 	return targetCmd
@@ -181,15 +406,79 @@ func (m *Model) updateComponent(view target.View, t target.Target, msg tea.Msg)
 // View renders the program's UI, which is just a string. The view is
 // rendered after every Update.
 func (m *Model) View() string {
-	s := lipgloss.JoinHorizontal(lipgloss.Top, m.Requests.View(), m.Editor.View())
+	var s string
+	switch m.CurrentView {
+	case target.EnvironmentView:
+		s = lipgloss.JoinHorizontal(lipgloss.Top, m.Environments.View(), m.EnvEditor.View())
+	case target.CookiesView:
+		s = m.Cookies.View()
+	default:
+		s = lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			m.Requests.View(),
+			lipgloss.JoinVertical(lipgloss.Left, m.Editor.View(), m.Response.View()),
+		)
+	}
 	// s = lipgloss.JoinVertical(lipgloss.Left, s, m.Help.View())
-	return s
+
+	return lipgloss.JoinVertical(lipgloss.Left, s, m.statusLine())
+}
+
+// statusLine renders the pending prompt, confirmation or the latest notification.
+func (m *Model) statusLine() string {
+	switch {
+	case m.prompt != nil:
+		return m.prompt.input.View()
+	case m.confirm != nil:
+		return styles.Title.Render(m.confirm.prompt + " (y/n)")
+	case m.Notification == nil:
+		return ""
+	case m.Notification.Level >= notification.Warn:
+		return styles.ErrorText.Render(m.Notification.Level.String() + ": " + m.Notification.Message)
+	default:
+		return m.Notification.Message
+	}
 }
 
 func (m *Model) handleKey(msg tea.KeyMsg) tea.Cmd {
+	m.Notification = nil
+
+	if p := m.prompt; p != nil {
+		switch msg.Type {
+		case tea.KeyEnter:
+			m.prompt = nil
+			return p.action(p.input.Value())
+		case tea.KeyEsc:
+			m.prompt = nil
+			return nil
+		}
+
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		return cmd
+	}
+
+	// any key other than "y" cancels a pending confirmation
+	if c := m.confirm; c != nil {
+		m.confirm = nil
+		if msg.String() == "y" {
+			return c.action()
+		}
+
+		return nil
+	}
+
 	switch {
 	case key.Matches(msg, m.Keys.Quit):
 		slog.Debug("quit key pressed")
+		if m.EnvEditor.Dirty() {
+			m.confirm = &confirmation{
+				prompt: fmt.Sprintf("Quit without saving changes to %s?", m.EnvEditor.Environment.Name),
+				action: func() tea.Cmd { return tea.Quit },
+			}
+			return nil
+		}
+
 		return tea.Quit
 	case key.Matches(msg, m.Keys.NextPane):
 		prevTarget := m.CurrentTarget
@@ -201,9 +490,224 @@ func (m *Model) handleKey(msg tea.KeyMsg) tea.Cmd {
 		m.CurrentTarget = target.PrevTarget(m.CurrentView, m.CurrentTarget)
 		slog.Debug("previous pane", slog.Any("updated_target", m.CurrentTarget))
 		return target.ChangeFocus(m.CurrentView, m.CurrentTarget, m.CurrentView, prevTarget)
+	case key.Matches(msg, m.Keys.SwitchView):
+		prevView, prevTarget := m.CurrentView, m.CurrentTarget
+		m.CurrentView = target.NextView(m.CurrentView)
+		m.CurrentTarget = target.FirstTarget(m.CurrentView)
+		slog.Debug("switch view", slog.Any("updated_view", m.CurrentView))
+		return target.ChangeFocus(m.CurrentView, m.CurrentTarget, prevView, prevTarget)
+	case key.Matches(msg, m.Keys.Send):
+		return m.send()
+	case key.Matches(msg, m.Keys.Save) && m.CurrentView == target.ClientView:
+		return m.save()
+	case key.Matches(msg, m.Keys.SaveAs) && m.CurrentView == target.ClientView:
+		return m.saveAs()
+	}
+
+	return m.updateComponent(m.CurrentView, m.CurrentTarget, msg)
+}
+
+// save writes the request in the editor to its group, asking for a name if it hasn't been saved before.
+func (m *Model) save() tea.Cmd {
+	r := m.Editor.CurrentRequest
+	if m.Requests.Group(r) != nil {
+		return m.Requests.Save(r)
 	}
 
-	// TODO: handle key presses for subcomponents
+	return m.ask("Save as", r.Name, func(name string) tea.Cmd {
+		old := r.Name
+		r.Name = name
+		cmd := m.Requests.Save(r)
+		// the request keeps its name if it couldn't be saved, e.g. because another request already has the name
+		if m.Requests.Group(r) == nil {
+			r.Name = old
+		}
+		return cmd
+	})
+}
+
+// saveAs saves a copy of the request in the editor under a new name, and continues editing the copy.
+func (m *Model) saveAs() tea.Cmd {
+	r := m.Editor.CurrentRequest
+	return m.ask("Save as", r.Name, func(name string) tea.Cmd {
+		c, cmd := m.Requests.SaveAs(r, name)
+		if c != nil {
+			m.Editor.Load(c, m.Requests.Group(c))
+		}
+
+		return cmd
+	})
+}
+
+// capture writes the values captured from resp by r into the active environment.
+func (m *Model) capture(r *request.Request, resp *request.Response) tea.Cmd {
+	if len(r.Captures) == 0 {
+		return nil
+	}
+
+	if m.Environment == nil {
+		return notification.Notify(notification.Warn, "No environment selected, captured values were discarded")
+	}
+
+	values, err := r.Capture(resp)
+	m.Environment.Capture(values)
+	slog.Debug("values captured", slog.String("request", r.Name), slog.Int("count", len(values)))
+
+	if err != nil {
+		return notification.Notify(notification.Warn, err.Error())
+	}
 
 	return nil
 }
+
+// send sends the request in the editor, after any requests it depends on that haven't been sent recently.
+func (m *Model) send() tea.Cmd {
+	r := m.Editor.CurrentRequest
+	if r == nil || r.Data == nil {
+		slog.Debug("no request to send")
+		return nil
+	}
+
+	deps, err := request.Dependencies(m.Requests.Requests, r)
+	if err != nil {
+		m.sent++
+		return tea.Batch(m.Response.Sending(m.sent, r), response.Fail(m.sent, r, err))
+	}
+
+	m.chain = m.chain[:0]
+	for _, dep := range deps {
+		if !m.fresh(dep) {
+			m.chain = append(m.chain, dep)
+		}
+	}
+	m.chain = append(m.chain, r)
+
+	return m.sendNext()
+}
+
+// sendNext sends the next request in the chain.
+func (m *Model) sendNext() tea.Cmd {
+	r := m.chain[0]
+	m.chain = m.chain[1:]
+
+	m.sent++
+	m.chainID = m.sent
+	m.latest[r] = m.sent
+	slog.Debug("sending request", slog.Int("id", m.sent), slog.String("name", r.Name), slog.Int("remaining", len(m.chain)))
+
+	if r.Data == nil {
+		return tea.Batch(m.Response.Sending(m.sent, r), response.Fail(m.sent, r, client.ErrNoData))
+	}
+
+	var vars map[string]any
+	if m.Environment != nil {
+		vars = m.Environment.Resolved()
+	}
+
+	// resolve variables before sending, so a request with missing variables is never sent
+	opts := []interpolate.Option{interpolate.WithRand(m.rand), interpolate.WithClock(m.now)}
+	if m.vault != nil {
+		opts = append(opts, interpolate.WithSecrets(m.vault.Get))
+	}
+
+	// group defaults are merged in first, so they can use variables too
+	data := r.Data
+	if group := m.Requests.Group(r); group != nil {
+		data = group.Apply(data)
+	}
+
+	data, err := interpolate.New(vars, opts...).Data(data)
+	if err != nil {
+		return tea.Batch(m.Response.Sending(m.sent, r), response.Fail(m.sent, r, err))
+	}
+
+	// the last response of the session is compared with, falling back to the last run of an earlier session
+	previous := r.Data.Response
+	if previous == nil {
+		previous = m.lastRun(r)
+	}
+
+	return tea.Batch(
+		m.Response.Sending(m.sent, r),
+		response.Send(m.ctx, m.Client, m.sent, r, data, previous),
+	)
+}
+
+// lastRun returns the status and timing of the last recorded run of r as a response, or nil if there is none.
+func (m *Model) lastRun(r *request.Request) *request.Response {
+	run := m.history.Last(history.Key(m.Requests.Group(r), r))
+	if run == nil {
+		return nil
+	}
+
+	return &request.Response{Status: run.Status, StatusCode: run.StatusCode, Timing: run.Timing}
+}
+
+// loadHistory reads the history of past runs in the background.
+func (m *Model) loadHistory() tea.Cmd {
+	h := m.history
+	return func() tea.Msg {
+		if err := h.Load(); err != nil {
+			slog.Error("failed to load the history", slog.Any("error", err))
+			return notification.Notification{Level: notification.Warn, Message: "loading timing history: " + err.Error()}
+		}
+
+		return nil
+	}
+}
+
+// record adds the run of r that received resp to the history in the background.
+func (m *Model) record(r *request.Request, resp *request.Response) tea.Cmd {
+	h, key := m.history, history.Key(m.Requests.Group(r), r)
+	return func() tea.Msg {
+		if err := h.Record(key, resp); err != nil {
+			slog.Error("failed to record the run", slog.Any("error", err))
+			return notification.Notification{Level: notification.Warn, Message: "recording timing: " + err.Error()}
+		}
+
+		return nil
+	}
+}
+
+// continueChain sends the next request in the chain once the previous one has succeeded. The rest of the chain is
+// abandoned if it failed.
+func (m *Model) continueChain(msg response.ResultMsg) tea.Cmd {
+	if msg.ID != m.chainID || len(m.chain) == 0 {
+		return nil
+	}
+
+	if msg.Err != nil || msg.Response.StatusCode >= http.StatusBadRequest {
+		last := m.chain[len(m.chain)-1]
+		m.chain = m.chain[:0]
+		return notification.Notify(
+			notification.Error,
+			fmt.Sprintf("Prerequisite %s of %s failed", msg.Request.Name, last.Name),
+		)
+	}
+
+	return m.sendNext()
+}
+
+// fresh reports whether r succeeded recently enough that it doesn't have to be sent again as a prerequisite, and the
+// values it captured are still in the active environment.
+func (m *Model) fresh(r *request.Request) bool {
+	if r.Data == nil || r.Data.Response == nil {
+		return false
+	}
+
+	resp := r.Data.Response
+	if resp.StatusCode >= http.StatusBadRequest || time.Since(resp.Timing.Start) > config.DependencyTTL() {
+		return false
+	}
+
+	for _, c := range r.Captures {
+		if m.Environment == nil {
+			return false
+		}
+		if _, ok := m.Environment.Captured[c.Name]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -13,12 +13,15 @@ const (
 	/* EnvironmentView targets */
 	EnvironmentsTarget
 	EnvEditorTarget
+	/* CookiesView targets */
+	CookiesTarget
 )
 
 /* TUI views */
 const (
 	ClientView View = iota
 	EnvironmentView
+	CookiesView
 )
 
 var targets = map[View][]Target{
@@ -31,6 +34,9 @@ var targets = map[View][]Target{
 		EnvironmentsTarget,
 		EnvEditorTarget,
 	},
+	CookiesView: {
+		CookiesTarget,
+	},
 }
 
 type (
@@ -50,6 +56,8 @@ func (t Target) String() string {
 		return "environments"
 	case EnvEditorTarget:
 		return "environment_editor"
+	case CookiesTarget:
+		return "cookies"
 	default:
 		return ""
 	}
@@ -61,6 +69,8 @@ func (v View) String() string {
 		return "client"
 	case EnvironmentView:
 		return "environment"
+	case CookiesView:
+		return "cookies"
 	default:
 		return ""
 	}
@@ -102,35 +112,21 @@ func PrevTarget(v View, t Target) Target {
 	return targets[v][idx]
 }
 
+// NextView returns the view after v, wrapping around to the first view.
 func NextView(v View) View {
-	delta := targetCount()
-	v -= View(delta) // remove count of targets to use 0-based indices
-
-	inc := int64(v) + 1
 	count := int64(len(targets))
-	return View((inc % count) + delta)
+	return View((int64(v) + 1) % count)
 }
 
+// PrevView returns the view before v, wrapping around to the last view.
 func PrevView(v View) View {
-	delta := targetCount()
-	v -= View(delta) // remove count of targets to use 0-based indices
-
-	if v == 0 {
-		return View(int64(len(targets)) - 1 + delta)
-	}
-
-	dec := int64(v) - 1
 	count := int64(len(targets))
-	return View((dec % count) + delta)
+	return View((int64(v) + count - 1) % count)
 }
 
-func targetCount() int64 {
-	count := 0
-	for _, v := range targets {
-		count += len(v)
-	}
-
-	return int64(count)
+// FirstTarget returns the target that is focused when switching to v.
+func FirstTarget(v View) Target {
+	return targets[v][0]
 }
 
 type FocusMsg struct {
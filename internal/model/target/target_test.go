@@ -39,6 +39,12 @@ func TestNextTarget(t *testing.T) {
 			currentTarget: target.EnvironmentsTarget,
 			expected:      target.EnvEditorTarget,
 		},
+		{
+			name:          "Cycle cookies focusedTarget",
+			view:          target.CookiesView,
+			currentTarget: target.CookiesTarget,
+			expected:      target.CookiesTarget,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -114,8 +120,13 @@ func TestNextView(t *testing.T) {
 			expected: target.EnvironmentView,
 		},
 		{
-			name:     "Cycle focusedView",
+			name:     "Next cookies focusedView",
 			current:  target.EnvironmentView,
+			expected: target.CookiesView,
+		},
+		{
+			name:     "Cycle focusedView",
+			current:  target.CookiesView,
 			expected: target.ClientView,
 		},
 	}
@@ -143,10 +154,15 @@ func TestPrevView(t *testing.T) {
 			current:  target.EnvironmentView,
 			expected: target.ClientView,
 		},
+		{
+			name:     "Previous environment focusedView",
+			current:  target.CookiesView,
+			expected: target.EnvironmentView,
+		},
 		{
 			name:     "Cycle focusedView",
 			current:  target.ClientView,
-			expected: target.EnvironmentView,
+			expected: target.CookiesView,
 		},
 	}
 
@@ -0,0 +1,335 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Package oauth gets OAuth 2.0 access tokens for requests and keeps them until they expire.
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+// authorizeTimeout is how long the user has to approve access in the browser.
+const authorizeTimeout = 5 * time.Minute
+
+// Token is an access token issued by an authorization server.
+type Token struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+	Scope        string
+	// Expiry is when the access token expires. It is zero if the server didn't say.
+	Expiry time.Time
+}
+
+// Type returns the scheme the access token is sent with in the Authorization header.
+func (t *Token) Type() string {
+	if t.TokenType == "" || strings.EqualFold(t.TokenType, "bearer") {
+		return "Bearer"
+	}
+
+	return t.TokenType
+}
+
+// expired reports whether the token has expired, or will within leeway of now.
+func (t *Token) expired(now time.Time, leeway time.Duration) bool {
+	return !t.Expiry.IsZero() && !now.Add(leeway).Before(t.Expiry)
+}
+
+// tokenResponse is the body of a response from a token endpoint, for both successful and failed requests.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	RefreshToken     string `json:"refresh_token"`
+	Scope            string `json:"scope"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// fetch gets a new token for auth with the grant it is configured with.
+func (s *Tokens) fetch(ctx context.Context, auth *request.Auth) (*Token, error) {
+	params := url.Values{}
+
+	switch auth.Grant {
+	case request.GrantClientCredentials, "":
+		params.Set("grant_type", request.GrantClientCredentials)
+	case request.GrantPassword:
+		params.Set("grant_type", request.GrantPassword)
+		params.Set("username", auth.Username)
+		params.Set("password", auth.Password)
+	case request.GrantRefreshToken:
+		return s.refresh(ctx, auth, auth.RefreshToken)
+	case request.GrantAuthorizationCode:
+		return s.authorizationCode(ctx, auth)
+	default:
+		return nil, fmt.Errorf("unsupported grant %q", auth.Grant)
+	}
+
+	if auth.Scope != "" {
+		params.Set("scope", auth.Scope)
+	}
+
+	return s.request(ctx, auth, params)
+}
+
+// refresh exchanges refreshToken for a new token.
+func (s *Tokens) refresh(ctx context.Context, auth *request.Auth, refreshToken string) (*Token, error) {
+	if refreshToken == "" {
+		return nil, errors.New("missing refresh token")
+	}
+
+	params := url.Values{
+		"grant_type":    {request.GrantRefreshToken},
+		"refresh_token": {refreshToken},
+	}
+	if auth.Scope != "" {
+		params.Set("scope", auth.Scope)
+	}
+
+	token, err := s.request(ctx, auth, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// servers may keep using the same refresh token without sending it again
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+
+	return token, nil
+}
+
+// authorizationCode sends the user to the authorization server to approve access, then exchanges the code the server
+// redirects back with for a token. PKCE protects the code, so the flow is safe for clients without a secret.
+func (s *Tokens) authorizationCode(ctx context.Context, auth *request.Auth) (*Token, error) {
+	if auth.AuthURL == "" {
+		return nil, errors.New("authorization code grant is missing the auth URL")
+	}
+
+	redirect := auth.RedirectURL
+	if redirect == "" {
+		redirect = "http://127.0.0.1:0/callback"
+	}
+
+	redirectURL, err := url.Parse(redirect)
+	if err != nil {
+		return nil, fmt.Errorf("parsing redirect URL: %w", err)
+	}
+	if redirectURL.Path == "" {
+		redirectURL.Path = "/"
+	}
+	// the code is received by a server listening on this machine, which must not be reachable from any other
+	if !loopback(redirectURL.Hostname()) {
+		return nil, fmt.Errorf("redirect URL %q must point at a loopback address such as 127.0.0.1", redirect)
+	}
+
+	listener, err := net.Listen("tcp", redirectURL.Host)
+	if err != nil {
+		return nil, fmt.Errorf("listening for redirect: %w", err)
+	}
+	defer listener.Close() // nolint:errcheck
+
+	// the port is only known once listening when a random one is used
+	if redirectURL.Port() == "0" {
+		redirectURL.Host = net.JoinHostPort(redirectURL.Hostname(), fmt.Sprint(listener.Addr().(*net.TCPAddr).Port))
+	}
+
+	verifier, state := randomString(), randomString()
+	challenge := sha256.Sum256([]byte(verifier))
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {auth.ClientID},
+		"redirect_uri":          {redirectURL.String()},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	if auth.Scope != "" {
+		params.Set("scope", auth.Scope)
+	}
+
+	authURL := auth.AuthURL + "?"
+	if strings.Contains(auth.AuthURL, "?") {
+		authURL = auth.AuthURL + "&"
+	}
+	authURL += params.Encode()
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+
+	srv := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != redirectURL.Path {
+				http.NotFound(w, r)
+				return
+			}
+
+			var res result
+			query := r.URL.Query()
+			switch {
+			case query.Get("error") != "":
+				res.err = fmt.Errorf("authorization denied: %s", describe(query.Get("error"), query.Get("error_description")))
+			case query.Get("state") != state:
+				res.err = errors.New("authorization response has the wrong state")
+			default:
+				res.code = query.Get("code")
+			}
+
+			if res.err != nil {
+				http.Error(w, res.err.Error(), http.StatusBadRequest)
+			} else {
+				_, _ = io.WriteString(w, "Authorized, you can close this window and return to go-rest.")
+			}
+
+			select {
+			case results <- res:
+			default:
+			}
+		}),
+	}
+	go srv.Serve(listener) // nolint:errcheck
+	defer srv.Close()      // nolint:errcheck
+
+	if err := s.open(authURL); err != nil {
+		return nil, fmt.Errorf("opening %s: %w", authURL, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, authorizeTimeout)
+	defer cancel()
+
+	var res result
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for authorization: %w", ctx.Err())
+	case res = <-results:
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	return s.request(ctx, auth, url.Values{
+		"grant_type":    {request.GrantAuthorizationCode},
+		"code":          {res.code},
+		"redirect_uri":  {redirectURL.String()},
+		"code_verifier": {verifier},
+	})
+}
+
+// request posts params to the token endpoint of auth and returns the token it responds with.
+func (s *Tokens) request(ctx context.Context, auth *request.Auth, params url.Values) (*Token, error) {
+	if auth.TokenURL == "" {
+		return nil, errors.New("missing token URL")
+	}
+
+	// clients with a secret authenticate with basic auth, public clients only identify themselves
+	if auth.ClientSecret == "" {
+		params.Set("client_id", auth.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.TokenURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("building token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if auth.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.ClientSecret))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting token: %w", err)
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading token response: %w", err)
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return nil, fmt.Errorf("token request failed with %s: %w", resp.Status, err)
+	}
+
+	switch {
+	case tr.Error != "":
+		return nil, fmt.Errorf("token request failed: %s", describe(tr.Error, tr.ErrorDescription))
+	case resp.StatusCode >= http.StatusBadRequest:
+		return nil, fmt.Errorf("token request failed with %s", resp.Status)
+	case tr.AccessToken == "":
+		return nil, errors.New("token response has no access token")
+	}
+
+	token := &Token{
+		AccessToken:  tr.AccessToken,
+		TokenType:    tr.TokenType,
+		RefreshToken: tr.RefreshToken,
+		Scope:        tr.Scope,
+	}
+	if tr.ExpiresIn > 0 {
+		token.Expiry = s.now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
+
+// describe joins an OAuth 2.0 error code with its description, if there is one.
+func describe(code, description string) string {
+	if description == "" {
+		return code
+	}
+
+	return code + ": " + description
+}
+
+// randomString returns a random URL safe string, used for PKCE verifiers and states.
+func randomString() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// loopback tells if host only refers to this machine.
+func loopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,240 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+// refreshLeeway is how long before it expires a token is refreshed, so it doesn't expire while a request is sent.
+const refreshLeeway = time.Minute
+
+// Option configures Tokens.
+type Option func(*Tokens)
+
+// WithHTTPClient sets the HTTP client used to request tokens.
+func WithHTTPClient(c *http.Client) Option {
+	return func(s *Tokens) {
+		s.client = c
+	}
+}
+
+// WithBrowser opens the pages the user approves access on with open, instead of the default browser.
+func WithBrowser(open func(url string) error) Option {
+	return func(s *Tokens) {
+		s.open = open
+	}
+}
+
+// WithClock takes the current time used to tell when tokens expire from now.
+func WithClock(now func() time.Time) Option {
+	return func(s *Tokens) {
+		s.now = now
+	}
+}
+
+// Entry is a cached token along with what it was issued for.
+type Entry struct {
+	TokenURL string
+	ClientID string
+	Scope    string
+	Token    *Token
+}
+
+// Tokens caches access tokens for each environment, since environments usually point at different authorization
+// servers or use different credentials. Tokens are requested when they are first needed, and refreshed shortly
+// before they expire.
+type Tokens struct {
+	client *http.Client
+	open   func(url string) error
+	now    func() time.Time
+
+	// mu guards the cached tokens and the locks in requesting.
+	mu sync.Mutex
+	// requesting holds a lock for each token while it is requested, which can take as long as the user takes to
+	// approve access, so only requests needing that same token wait for it.
+	requesting map[string]*sync.Mutex
+	// env is the environment tokens are currently requested for.
+	env     string
+	entries map[string]map[string]*Entry
+}
+
+// New creates an empty token cache and applies the provided options.
+func New(opts ...Option) *Tokens {
+	s := &Tokens{
+		client:  http.DefaultClient,
+		open:    OpenBrowser,
+		now:     time.Now,
+		entries: make(map[string]map[string]*Entry),
+
+		requesting: make(map[string]*sync.Mutex),
+	}
+
+	for _, optFunc := range opts {
+		optFunc(s)
+	}
+
+	return s
+}
+
+// Select caches the tokens requested from now on for the environment with the given name.
+func (s *Tokens) Select(env string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.env = env
+}
+
+// Token returns an access token for auth, requesting a new one if there is no cached token or it has expired. An
+// expiring token is refreshed if the server issued a refresh token with it.
+func (s *Tokens) Token(ctx context.Context, auth *request.Auth) (*Token, error) {
+	s.mu.Lock()
+	env, key := s.env, cacheKey(auth)
+	entry := s.entries[env][key]
+	lock, ok := s.requesting[env+"\x00"+key]
+	if !ok {
+		lock = new(sync.Mutex)
+		s.requesting[env+"\x00"+key] = lock
+	}
+	s.mu.Unlock()
+
+	if entry != nil && !entry.Token.expired(s.now(), refreshLeeway) {
+		return entry.Token, nil
+	}
+
+	// each token is requested once at a time, so it isn't requested twice when requests are sent back to back; the
+	// cache is checked again once the lock is held, since the token may have been requested while waiting for it
+	lock.Lock()
+	defer lock.Unlock()
+
+	s.mu.Lock()
+	entry = s.entries[env][key]
+	s.mu.Unlock()
+
+	if entry != nil && !entry.Token.expired(s.now(), refreshLeeway) {
+		return entry.Token, nil
+	}
+
+	var (
+		token *Token
+		err   error
+	)
+	if entry != nil && entry.Token.RefreshToken != "" {
+		slog.Debug("refreshing token", slog.String("token_url", auth.TokenURL), slog.String("client_id", auth.ClientID))
+		token, err = s.refresh(ctx, auth, entry.Token.RefreshToken)
+	}
+	if token == nil {
+		if err != nil {
+			slog.Debug("refreshing token failed, requesting a new one", slog.Any("error", err))
+		}
+
+		slog.Debug("requesting token", slog.String("token_url", auth.TokenURL), slog.String("grant", auth.Grant))
+		if token, err = s.fetch(ctx, auth); err != nil {
+			return nil, fmt.Errorf("getting OAuth 2.0 token: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entries[env] == nil {
+		s.entries[env] = make(map[string]*Entry)
+	}
+	s.entries[env][key] = &Entry{TokenURL: auth.TokenURL, ClientID: auth.ClientID, Scope: auth.Scope, Token: token}
+
+	return token, nil
+}
+
+// List returns the tokens cached for the environment with the given name, ordered by token URL and client.
+func (s *Tokens) List(env string) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]Entry, 0, len(s.entries[env]))
+	for _, entry := range s.entries[env] {
+		list = append(list, *entry)
+	}
+
+	slices.SortFunc(list, func(a, b Entry) int {
+		if c := strings.Compare(a.TokenURL, b.TokenURL); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ClientID, b.ClientID)
+	})
+
+	return list
+}
+
+// Clear forgets the tokens cached for the environment with the given name.
+func (s *Tokens) Clear(env string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, env)
+}
+
+// cacheKey identifies the token of auth, so requests sharing credentials share a token. Every credential is part of
+// the key, so a token issued for credentials that have since been corrected isn't used anymore. The key is hashed, so
+// secrets aren't kept in plain text beside the token.
+func cacheKey(auth *request.Auth) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		auth.Grant,
+		auth.TokenURL,
+		auth.AuthURL,
+		auth.RedirectURL,
+		auth.ClientID,
+		auth.ClientSecret,
+		auth.Username,
+		auth.Password,
+		auth.RefreshToken,
+		auth.Scope,
+	}, "\x00")))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// OpenBrowser opens url in the default browser of the user.
+func OpenBrowser(url string) error {
+	slog.Info("opening browser to authorize", slog.String("url", url))
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	return cmd.Start()
+}
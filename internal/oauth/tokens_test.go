@@ -0,0 +1,293 @@
+package oauth_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/oauth"
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+// authServer is a minimal authorization server issuing numbered tokens.
+type authServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	issued  int
+	grants  []string
+	pending map[string]string // code challenges by code
+}
+
+func newAuthServer(t *testing.T) *authServer {
+	s := &authServer{pending: make(map[string]string)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		query := r.URL.Query()
+		code := fmt.Sprintf("code-%d", len(s.pending))
+		s.pending[code] = query.Get("code_challenge")
+
+		redirect := query.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+		http.Redirect(w, r, redirect, http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		require.NoError(t, r.ParseForm())
+		grant := r.PostForm.Get("grant_type")
+		s.grants = append(s.grants, grant)
+
+		fail := func(code string) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": code, "error_description": "rejected"})
+		}
+
+		id, secret, _ := r.BasicAuth()
+		switch grant {
+		case request.GrantClientCredentials:
+			if id != "client" || secret != "secret" {
+				fail("invalid_client")
+				return
+			}
+		case request.GrantPassword:
+			if r.PostForm.Get("username") != "user" || r.PostForm.Get("password") != "pass" {
+				fail("invalid_grant")
+				return
+			}
+		case request.GrantRefreshToken:
+			if r.PostForm.Get("refresh_token") != "refresh" {
+				fail("invalid_grant")
+				return
+			}
+		case request.GrantAuthorizationCode:
+			challenge, ok := s.pending[r.PostForm.Get("code")]
+			sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+			if !ok || r.PostForm.Get("client_id") != "public" || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+				fail("invalid_grant")
+				return
+			}
+		}
+
+		s.issued++
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  fmt.Sprintf("token-%d", s.issued),
+			"token_type":    "bearer",
+			"refresh_token": "refresh",
+			"expires_in":    3600,
+		})
+	})
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func TestTokens_Token(t *testing.T) {
+	testCases := []struct {
+		name        string
+		auth        *request.Auth
+		expectedErr string
+	}{
+		{
+			name: "Client credentials",
+			auth: &request.Auth{Grant: request.GrantClientCredentials, ClientID: "client", ClientSecret: "secret"},
+		},
+		{
+			name: "Password",
+			auth: &request.Auth{Grant: request.GrantPassword, ClientID: "client", Username: "user", Password: "pass"},
+		},
+		{
+			name: "Refresh token",
+			auth: &request.Auth{Grant: request.GrantRefreshToken, ClientID: "client", RefreshToken: "refresh"},
+		},
+		{
+			name: "Authorization code with PKCE",
+			auth: &request.Auth{Grant: request.GrantAuthorizationCode, ClientID: "public"},
+		},
+		{
+			name:        "Rejected",
+			auth:        &request.Auth{Grant: request.GrantClientCredentials, ClientID: "client", ClientSecret: "wrong"},
+			expectedErr: "invalid_client: rejected",
+		},
+		{
+			name: "Non-loopback redirect",
+			auth: &request.Auth{
+				Grant:       request.GrantAuthorizationCode,
+				ClientID:    "public",
+				RedirectURL: "http://example.com:8080/callback",
+			},
+			expectedErr: "must point at a loopback address",
+		},
+		{
+			name:        "Unknown grant",
+			auth:        &request.Auth{Grant: "implicit"},
+			expectedErr: `unsupported grant "implicit"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				srv := newAuthServer(t)
+				tc.auth.Type = request.AuthOAuth2
+				tc.auth.TokenURL = srv.URL + "/token"
+				tc.auth.AuthURL = srv.URL + "/authorize"
+
+				// the browser approves access right away, following the redirect back to the loopback listener
+				browser := func(authURL string) error {
+					resp, err := http.Get(authURL)
+					if err != nil {
+						return err
+					}
+					return resp.Body.Close()
+				}
+
+				tokens := oauth.New(oauth.WithBrowser(browser))
+				token, err := tokens.Token(context.Background(), tc.auth)
+				if tc.expectedErr != "" {
+					assert.ErrorContains(t, err, tc.expectedErr)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, "token-1", token.AccessToken)
+				assert.Equal(t, "Bearer", token.Type())
+			},
+		)
+	}
+}
+
+func TestTokens_Cache(t *testing.T) {
+	srv := newAuthServer(t)
+	auth := &request.Auth{
+		Type:         request.AuthOAuth2,
+		Grant:        request.GrantClientCredentials,
+		TokenURL:     srv.URL + "/token",
+		ClientID:     "client",
+		ClientSecret: "secret",
+	}
+
+	now := time.Now()
+	tokens := oauth.New(oauth.WithClock(func() time.Time { return now }))
+	tokens.Select("dev")
+
+	get := func() string {
+		token, err := tokens.Token(context.Background(), auth)
+		require.NoError(t, err)
+		return token.AccessToken
+	}
+
+	assert.Equal(t, "token-1", get())
+	assert.Equal(t, "token-1", get(), "cached token")
+
+	// each environment has its own tokens
+	tokens.Select("prod")
+	assert.Equal(t, "token-2", get())
+	tokens.Select("dev")
+	assert.Equal(t, "token-1", get())
+
+	// the token is refreshed shortly before it expires
+	now = now.Add(59*time.Minute + 30*time.Second)
+	assert.Equal(t, "token-3", get())
+	assert.Equal(t, []string{
+		request.GrantClientCredentials,
+		request.GrantClientCredentials,
+		request.GrantRefreshToken,
+	}, srv.grants)
+
+	entries := tokens.List("dev")
+	require.Len(t, entries, 1)
+	assert.Equal(t, "client", entries[0].ClientID)
+	assert.Equal(t, "token-3", entries[0].Token.AccessToken)
+
+	// changed credentials don't reuse the token issued for the previous ones
+	changed := *auth
+	changed.ClientSecret = "wrong"
+	_, err := tokens.Token(context.Background(), &changed)
+	assert.ErrorContains(t, err, "invalid_client")
+
+	tokens.Clear("dev")
+	assert.Empty(t, tokens.List("dev"))
+	assert.Len(t, tokens.List("prod"), 1)
+}
+
+func TestTokens_Concurrent(t *testing.T) {
+	srv := newAuthServer(t)
+	credentials := &request.Auth{
+		Type:         request.AuthOAuth2,
+		Grant:        request.GrantClientCredentials,
+		TokenURL:     srv.URL + "/token",
+		ClientID:     "client",
+		ClientSecret: "secret",
+	}
+	code := &request.Auth{
+		Type:     request.AuthOAuth2,
+		Grant:    request.GrantAuthorizationCode,
+		TokenURL: srv.URL + "/token",
+		AuthURL:  srv.URL + "/authorize",
+		ClientID: "public",
+	}
+
+	// the user takes their time to approve access
+	opened, approve := make(chan struct{}), make(chan struct{})
+	browser := func(authURL string) error {
+		close(opened)
+		<-approve
+
+		resp, err := http.Get(authURL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+	tokens := oauth.New(oauth.WithBrowser(browser))
+
+	approved := make(chan error, 1)
+	go func() {
+		_, err := tokens.Token(context.Background(), code)
+		approved <- err
+	}()
+	<-opened
+
+	// other tokens don't wait for the approval, and a token requested twice at once is only issued once
+	var wg sync.WaitGroup
+	got := make([]string, 2)
+	for i := range got {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			token, err := tokens.Token(context.Background(), credentials)
+			assert.NoError(t, err)
+			if token != nil {
+				got[i] = token.AccessToken
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"token-1", "token-1"}, got)
+
+	close(approve)
+	require.NoError(t, <-approved)
+	assert.Equal(t, []string{request.GrantClientCredentials, request.GrantAuthorizationCode}, srv.grants)
+}
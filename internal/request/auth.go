@@ -0,0 +1,103 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package request
+
+// The types of authentication a request can use.
+const (
+	AuthNone   = ""
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+	AuthAPIKey = "api_key"
+	AuthDigest = "digest"
+	AuthAWS    = "aws_sigv4"
+	AuthOAuth2 = "oauth2"
+)
+
+// AuthTypes lists every type of authentication, in the order they are offered in the editor.
+var AuthTypes = []string{AuthNone, AuthBasic, AuthBearer, AuthAPIKey, AuthDigest, AuthAWS, AuthOAuth2}
+
+// Where an API key is sent.
+const (
+	APIKeyInHeader = "header"
+	APIKeyInQuery  = "query"
+)
+
+// The OAuth 2.0 grants used to get access tokens.
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantPassword          = "password"
+	GrantRefreshToken      = "refresh_token"
+	GrantAuthorizationCode = "authorization_code"
+)
+
+// Auth describes how a request is authenticated. The client adds the credentials to the request when it is sent, so
+// they don't have to be written into the headers by hand.
+type Auth struct {
+	Type string `json:"type,omitempty"`
+	// Username and Password are used for basic and digest authentication.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Token is used for bearer authentication.
+	Token string `json:"token,omitempty"`
+	// Key is the name of the header or query parameter an API key is sent in, and Value is the API key itself.
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value,omitempty"`
+	// In is where the API key is sent, either APIKeyInHeader or APIKeyInQuery. Headers are used by default.
+	In string `json:"in,omitempty"`
+	// AccessKey, SecretKey and SessionToken are the AWS credentials used to sign requests. The session token is only
+	// needed for temporary credentials.
+	AccessKey    string `json:"access_key,omitempty"`
+	SecretKey    string `json:"secret_key,omitempty"`
+	SessionToken string `json:"session_token,omitempty"`
+	// Region and Service scope AWS signatures, e.g. "us-east-1" and "execute-api".
+	Region  string `json:"region,omitempty"`
+	Service string `json:"service,omitempty"`
+	// Grant is how an OAuth 2.0 access token is requested from the token URL. The password grant also uses the
+	// username and password, and the authorization code grant sends the user to the auth URL first.
+	Grant        string `json:"grant,omitempty"`
+	TokenURL     string `json:"token_url,omitempty"`
+	AuthURL      string `json:"auth_url,omitempty"`
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	// Scope is the space separated list of scopes requested.
+	Scope        string `json:"scope,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// RedirectURL is where the authorization server sends the user back to. It must be a loopback address, and a
+	// random port on 127.0.0.1 is used if it is empty.
+	RedirectURL string `json:"redirect_url,omitempty"`
+}
+
+// Fields returns pointers to every credential of a, so they can be filled in or rewritten together.
+func (a *Auth) Fields() []*string {
+	return []*string{
+		&a.Username, &a.Password, &a.Token, &a.Key, &a.Value, &a.In,
+		&a.AccessKey, &a.SecretKey, &a.SessionToken, &a.Region, &a.Service,
+		&a.Grant, &a.TokenURL, &a.AuthURL, &a.ClientID, &a.ClientSecret, &a.Scope, &a.RefreshToken, &a.RedirectURL,
+	}
+}
+
+// Clone returns a copy of a.
+func (a *Auth) Clone() *Auth {
+	if a == nil {
+		return nil
+	}
+
+	c := *a
+	return &c
+}
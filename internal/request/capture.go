@@ -0,0 +1,169 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Capture extracts a value from a response into a variable, so later requests can reference it. Exactly one of
+// JSONPath, Header, Regex or Cookie selects where the value comes from.
+type Capture struct {
+	// Name is the variable the value is written to.
+	Name string `json:"name"`
+	// JSONPath selects a value from a JSON body, e.g. "$.data.token" or "$.items[0].id".
+	JSONPath string `json:"json_path,omitempty"`
+	// Header is the name of a response header.
+	Header string `json:"header,omitempty"`
+	// Regex is matched against the body. The first capture group is used if there is one, otherwise the whole match.
+	Regex string `json:"regex,omitempty"`
+	// Cookie is the name of a cookie set by the response.
+	Cookie string `json:"cookie,omitempty"`
+}
+
+// ErrNotCaptured is returned when a capture doesn't match anything in a response.
+var ErrNotCaptured = errors.New("no match")
+
+// Extract returns the value selected by c from resp.
+func (c Capture) Extract(resp *Response) (any, error) {
+	switch {
+	case c.JSONPath != "":
+		var body any
+		if err := json.Unmarshal(resp.Body, &body); err != nil {
+			return nil, fmt.Errorf("parsing body: %w", err)
+		}
+		return jsonPath(body, c.JSONPath)
+	case c.Header != "":
+		values := http.Header(resp.Headers).Values(c.Header)
+		if len(values) == 0 {
+			return nil, ErrNotCaptured
+		}
+		return values[0], nil
+	case c.Regex != "":
+		re, err := regexp.Compile(c.Regex)
+		if err != nil {
+			return nil, err
+		}
+		match := re.FindSubmatch(resp.Body)
+		if match == nil {
+			return nil, ErrNotCaptured
+		}
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+		return string(match[0]), nil
+	case c.Cookie != "":
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == c.Cookie {
+				return cookie.Value, nil
+			}
+		}
+		return nil, ErrNotCaptured
+	default:
+		return nil, errors.New("no source to capture from")
+	}
+}
+
+// Capture extracts every capture of r from resp. Captures that fail are skipped and their errors returned together.
+func (r *Request) Capture(resp *Response) (map[string]any, error) {
+	values := make(map[string]any, len(r.Captures))
+
+	var errs []error
+	for _, c := range r.Captures {
+		value, err := c.Extract(resp)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("capturing %s: %w", c.Name, err))
+			continue
+		}
+		values[c.Name] = value
+	}
+
+	return values, errors.Join(errs...)
+}
+
+// jsonPath selects the value at path in body. Only the subset of JSONPath needed to reach a single value is supported:
+// the root "$", child names with ".name" or "['name']", and array indexes with "[0]".
+func jsonPath(body any, path string) (any, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("invalid JSONPath %q: must start with $", path)
+	}
+
+	current := body
+	for rest != "" {
+		var (
+			name  string
+			index = -1
+		)
+
+		switch {
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			name, rest = rest[:end], rest[end:]
+		case strings.HasPrefix(rest, "['"), strings.HasPrefix(rest, `["`):
+			quote := rest[1:2]
+			end := strings.Index(rest[2:], quote+"]")
+			if end == -1 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unterminated name", path)
+			}
+			name, rest = rest[2:2+end], rest[2+end+2:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unterminated index", path)
+			}
+			i, err := strconv.Atoi(rest[1:end])
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: invalid index %s", path, rest[1:end])
+			}
+			index, rest = i, rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q", path)
+		}
+
+		if index >= 0 {
+			list, ok := current.([]any)
+			if !ok || index >= len(list) {
+				return nil, ErrNotCaptured
+			}
+			current = list[index]
+			continue
+		}
+
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil, ErrNotCaptured
+		}
+		if current, ok = obj[name]; !ok {
+			return nil, ErrNotCaptured
+		}
+	}
+
+	return current, nil
+}
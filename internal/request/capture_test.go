@@ -0,0 +1,118 @@
+package request_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+func TestCapture_Extract(t *testing.T) {
+	resp := &request.Response{
+		Headers: map[string][]string{
+			"X-Request-Id": {"req-1"},
+			"Set-Cookie":   {"session=abc; Path=/; HttpOnly"},
+		},
+		Body: []byte(`{"data": {"token": "t0k3n", "user.name": "alice"}, "items": [{"id": 1}, {"id": 2}]}`),
+	}
+
+	testCases := []struct {
+		name         string
+		capture      request.Capture
+		expected     any
+		expectingErr string
+	}{
+		{
+			name:     "JSONPath",
+			capture:  request.Capture{JSONPath: "$.data.token"},
+			expected: "t0k3n",
+		},
+		{
+			name:     "JSONPath index",
+			capture:  request.Capture{JSONPath: "$.items[1].id"},
+			expected: float64(2),
+		},
+		{
+			name:     "JSONPath bracket name",
+			capture:  request.Capture{JSONPath: "$.data['user.name']"},
+			expected: "alice",
+		},
+		{
+			name:     "JSONPath object",
+			capture:  request.Capture{JSONPath: "$.items[0]"},
+			expected: map[string]any{"id": float64(1)},
+		},
+		{
+			name:         "JSONPath missing",
+			capture:      request.Capture{JSONPath: "$.items[5].id"},
+			expectingErr: "no match",
+		},
+		{
+			name:         "Invalid JSONPath",
+			capture:      request.Capture{JSONPath: "data.token"},
+			expectingErr: "must start with $",
+		},
+		{
+			name:     "Header",
+			capture:  request.Capture{Header: "x-request-id"},
+			expected: "req-1",
+		},
+		{
+			name:     "Regex group",
+			capture:  request.Capture{Regex: `"token": "(\w+)"`},
+			expected: "t0k3n",
+		},
+		{
+			name:     "Regex match",
+			capture:  request.Capture{Regex: `t0k\w+`},
+			expected: "t0k3n",
+		},
+		{
+			name:     "Cookie",
+			capture:  request.Capture{Cookie: "session"},
+			expected: "abc",
+		},
+		{
+			name:         "Missing cookie",
+			capture:      request.Capture{Cookie: "other"},
+			expectingErr: "no match",
+		},
+		{
+			name:         "No source",
+			capture:      request.Capture{Name: "token"},
+			expectingErr: "no source",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				actual, err := tc.capture.Extract(resp)
+				if tc.expectingErr != "" {
+					assert.ErrorContains(t, err, tc.expectingErr)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, actual)
+			},
+		)
+	}
+}
+
+func TestRequest_Capture(t *testing.T) {
+	r := &request.Request{
+		Captures: []request.Capture{
+			{Name: "token", JSONPath: "$.token"},
+			{Name: "missing", Header: "X-Missing"},
+		},
+	}
+
+	values, err := r.Capture(&request.Response{Body: []byte(`{"token": "abc"}`)})
+	assert.ErrorContains(t, err, "capturing missing: no match")
+	assert.Equal(t, map[string]any{"token": "abc"}, values)
+}
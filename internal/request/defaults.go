@@ -0,0 +1,208 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration written as a string such as "30s" in request files.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(body []byte) error {
+	var s string
+	if err := json.Unmarshal(body, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %w", err)
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// Defaults are the values shared by every request in a group, including the groups nested in it. Values set on a
+// request win over the defaults.
+type Defaults struct {
+	// BaseURL is prepended to request URLs that are relative.
+	BaseURL string `json:"base_url,omitempty"`
+	// Headers are added to requests that don't set or disable a header with the same name.
+	Headers map[string][]string `json:"headers,omitempty"`
+	// Query parameters are added to request URLs that don't already have them.
+	Query     map[string]string `json:"query,omitempty"`
+	Auth      *Auth             `json:"auth,omitempty"`
+	Timeout   Duration          `json:"timeout,omitempty"`
+	Redirects *Redirects        `json:"redirects,omitempty"`
+}
+
+// ResolvedDefaults returns the defaults of the group merged with those of every group it is nested in, with the values
+// of the innermost group winning. It returns nil if none of them have defaults.
+func (group *Group) ResolvedDefaults() *Defaults {
+	var parent *Defaults
+	if group.parent != nil {
+		parent = group.parent.ResolvedDefaults()
+	}
+
+	switch {
+	case parent == nil:
+		return group.Defaults
+	case group.Defaults == nil:
+		return parent
+	}
+
+	d := *parent
+	d.Headers = cloneHeaders(parent.Headers)
+	d.Query = make(map[string]string, len(parent.Query))
+	for k, v := range parent.Query {
+		d.Query[k] = v
+	}
+
+	own := group.Defaults
+	if own.BaseURL != "" {
+		d.BaseURL = own.BaseURL
+	}
+	for name, values := range own.Headers {
+		if d.Headers == nil {
+			d.Headers = make(map[string][]string)
+		}
+		d.Headers[name] = values
+	}
+	for k, v := range own.Query {
+		d.Query[k] = v
+	}
+	if own.Auth != nil {
+		d.Auth = own.Auth
+	}
+	if own.Timeout != 0 {
+		d.Timeout = own.Timeout
+	}
+	if own.Redirects != nil {
+		d.Redirects = own.Redirects
+	}
+
+	return &d
+}
+
+// Apply returns a copy of data with the defaults of the group merged into it.
+func (group *Group) Apply(data *Data) *Data {
+	c := data.Clone()
+
+	defaults := group.ResolvedDefaults()
+	if defaults == nil {
+		return c
+	}
+
+	if defaults.BaseURL != "" && relative(c.URL) {
+		c.URL = strings.TrimRight(defaults.BaseURL, "/") + "/" + strings.TrimLeft(c.URL, "/")
+	}
+
+	for name, values := range defaults.InheritedHeaders(c) {
+		if c.Headers == nil {
+			c.Headers = make(map[string][]string)
+		}
+		c.Headers[name] = append([]string(nil), values...)
+	}
+
+	if len(defaults.Query) > 0 {
+		base, query, fragment := SplitURL(c.URL)
+
+		existing := make(map[string]bool)
+		for _, param := range strings.Split(query, "&") {
+			key, _, _ := strings.Cut(param, "=")
+			existing[UnescapeQuery(key)] = true
+		}
+
+		params := make([]string, 0, len(defaults.Query))
+		if query != "" {
+			params = append(params, query)
+		}
+		for _, key := range sortedKeys(defaults.Query) {
+			if !existing[key] {
+				params = append(params, EscapeQuery(key)+"="+EscapeQuery(defaults.Query[key]))
+			}
+		}
+
+		c.URL = base
+		if len(params) > 0 {
+			c.URL += "?" + strings.Join(params, "&")
+		}
+		c.URL += fragment
+	}
+
+	if c.Auth == nil {
+		c.Auth = defaults.Auth.Clone()
+	}
+
+	if c.Timeout == 0 {
+		c.Timeout = defaults.Timeout
+	}
+
+	if c.Redirects == nil {
+		c.Redirects = defaults.Redirects.Clone()
+	}
+
+	return c
+}
+
+// InheritedHeaders returns the default headers that apply to data, leaving out any it sets or disables itself.
+func (d *Defaults) InheritedHeaders(data *Data) map[string][]string {
+	own := make(map[string]bool)
+	for _, headers := range []map[string][]string{data.Headers, data.DisabledHeaders} {
+		for name := range headers {
+			own[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+
+	inherited := make(map[string][]string)
+	for name, values := range d.Headers {
+		if !own[http.CanonicalHeaderKey(name)] {
+			inherited[name] = values
+		}
+	}
+
+	return inherited
+}
+
+// relative reports whether rawURL has to be joined to a base URL. URLs with a scheme, or starting with a placeholder
+// that could hold one, are used as they are.
+func relative(rawURL string) bool {
+	return !strings.Contains(rawURL, "://") && !strings.HasPrefix(rawURL, "{{")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
@@ -0,0 +1,133 @@
+package request_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+func TestGroup_Apply(t *testing.T) {
+	dir := t.TempDir()
+	body := `name: api
+defaults:
+  base_url: https://{{host}}/v1/
+  headers:
+    Accept: [application/json]
+    X-Trace: ["on"]
+  query:
+    api_key: "{{key}}"
+    page: "1"
+  auth:
+    type: bearer
+    token: group
+  timeout: 30s
+  redirects:
+    max: 3
+groups:
+  - name: admin
+    defaults:
+      headers:
+        Accept: [text/plain]
+      timeout: 5s
+      redirects:
+        follow: false
+`
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "requests"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "requests", "api.yaml"), []byte(body), 0644))
+
+	groups, warnings, err := request.LoadFrom(dir)
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+	require.Len(t, groups, 1)
+
+	api := groups[0]
+	admin := api.Groups[0]
+	follow := false
+
+	testCases := []struct {
+		name     string
+		group    *request.Group
+		data     *request.Data
+		expected *request.Data
+	}{
+		{
+			name:  "Defaults",
+			group: api,
+			data:  &request.Data{URL: "/users?page=2#top"},
+			expected: &request.Data{
+				URL: "https://{{host}}/v1/users?page=2&api_key={{key}}#top",
+				Headers: map[string][]string{
+					"Accept":  {"application/json"},
+					"X-Trace": {"on"},
+				},
+				Auth:      &request.Auth{Type: request.AuthBearer, Token: "group"},
+				Timeout:   request.Duration(30 * time.Second),
+				Redirects: &request.Redirects{Max: 3},
+			},
+		},
+		{
+			name:  "Request values win",
+			group: api,
+			data: &request.Data{
+				URL:             "http://localhost/users",
+				Headers:         map[string][]string{"accept": {"text/html"}},
+				DisabledHeaders: map[string][]string{"X-Trace": {"off"}},
+				Auth:            &request.Auth{Type: request.AuthBasic, Username: "me"},
+				Timeout:         request.Duration(time.Second),
+				Redirects:       &request.Redirects{PreserveMethod: true},
+			},
+			expected: &request.Data{
+				URL:             "http://localhost/users?api_key={{key}}&page=1",
+				Headers:         map[string][]string{"accept": {"text/html"}},
+				DisabledHeaders: map[string][]string{"X-Trace": {"off"}},
+				Auth:            &request.Auth{Type: request.AuthBasic, Username: "me"},
+				Timeout:         request.Duration(time.Second),
+				Redirects:       &request.Redirects{PreserveMethod: true},
+			},
+		},
+		{
+			name:  "Nested group",
+			group: admin,
+			data:  &request.Data{URL: "{{admin_url}}/users"},
+			expected: &request.Data{
+				URL: "{{admin_url}}/users?api_key={{key}}&page=1",
+				Headers: map[string][]string{
+					"Accept":  {"text/plain"},
+					"X-Trace": {"on"},
+				},
+				Auth:      &request.Auth{Type: request.AuthBearer, Token: "group"},
+				Timeout:   request.Duration(5 * time.Second),
+				Redirects: &request.Redirects{Follow: &follow},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				original := tc.data.Clone()
+
+				assert.Equal(t, tc.expected, tc.group.Apply(tc.data))
+				// the request itself is left untouched
+				assert.Equal(t, original, tc.data)
+			},
+		)
+	}
+}
+
+func TestDuration_UnmarshalJSON(t *testing.T) {
+	var d request.Duration
+	require.NoError(t, d.UnmarshalJSON([]byte(`"1m30s"`)))
+	assert.Equal(t, request.Duration(90*time.Second), d)
+
+	assert.Error(t, d.UnmarshalJSON([]byte(`90`)))
+	assert.Error(t, d.UnmarshalJSON([]byte(`"soon"`)))
+}
@@ -0,0 +1,104 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package request
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Dependencies returns the requests r depends on, directly or indirectly, in the order they have to be sent. Each
+// entry of DependsOn references a request as "group/name", where the group is its name or its full name if it is
+// nested, or by name alone within the group of r. An error is returned if a reference can't be found or if requests
+// depend on each other in a cycle.
+func Dependencies(groups []*Group, r *Request) ([]*Request, error) {
+	var (
+		order   []*Request
+		visited = make(map[*Request]bool)
+		visit   func(r *Request, group string, path []string) error
+	)
+
+	visit = func(r *Request, group string, path []string) error {
+		for _, ref := range r.DependsOn {
+			dep, depGroup, err := find(groups, group, ref)
+			if err != nil {
+				return fmt.Errorf("%s depends on %s: %w", r.Name, ref, err)
+			}
+
+			name := depGroup + "/" + dep.Name
+			if slices.Contains(path, name) {
+				return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, name), " -> "))
+			}
+
+			if visited[dep] {
+				continue
+			}
+
+			if err = visit(dep, depGroup, append(path, name)); err != nil {
+				return err
+			}
+
+			visited[dep] = true
+			order = append(order, dep)
+		}
+
+		return nil
+	}
+
+	group := groupOf(groups, r)
+	if err := visit(r, group, []string{group + "/" + r.Name}); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// groupOf returns the name of the group r belongs to, or an empty string if it isn't in any of them.
+func groupOf(groups []*Group, r *Request) string {
+	for _, g := range All(groups) {
+		if slices.Contains(g.Requests, r) {
+			return g.FullName()
+		}
+	}
+
+	return ""
+}
+
+// find returns the request referenced by ref, along with the name of its group.
+func find(groups []*Group, group, ref string) (*Request, string, error) {
+	name := ref
+	if i := strings.LastIndex(ref, "/"); i >= 0 {
+		group, name = ref[:i], ref[i+1:]
+	}
+
+	for _, g := range All(groups) {
+		if g.FullName() != group && g.Name != group {
+			continue
+		}
+
+		for _, r := range g.Requests {
+			if r.Name == name {
+				return r, g.FullName(), nil
+			}
+		}
+	}
+
+	return nil, "", fmt.Errorf("request not found")
+}
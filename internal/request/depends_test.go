@@ -0,0 +1,76 @@
+package request_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+func TestDependencies(t *testing.T) {
+	var (
+		login  = &request.Request{Name: "login"}
+		create = &request.Request{Name: "create order", DependsOn: []string{"auth/login"}}
+		get    = &request.Request{Name: "get order", DependsOn: []string{"create order", "auth/login"}}
+		cycleA = &request.Request{Name: "a", DependsOn: []string{"b"}}
+		cycleB = &request.Request{Name: "b", DependsOn: []string{"a"}}
+		broken = &request.Request{Name: "broken", DependsOn: []string{"auth/missing"}}
+	)
+
+	groups := []*request.Group{
+		{Name: "auth", Requests: []*request.Request{login}},
+		{Name: "orders", Requests: []*request.Request{create, get, broken}},
+		{Name: "cycle", Requests: []*request.Request{cycleA, cycleB}},
+	}
+
+	testCases := []struct {
+		name         string
+		request      *request.Request
+		expected     []*request.Request
+		expectingErr string
+	}{
+		{
+			name:    "No dependencies",
+			request: login,
+		},
+		{
+			name:     "Direct dependency",
+			request:  create,
+			expected: []*request.Request{login},
+		},
+		{
+			name:     "Shared dependency is only sent once",
+			request:  get,
+			expected: []*request.Request{login, create},
+		},
+		{
+			name:         "Cycle",
+			request:      cycleA,
+			expectingErr: "dependency cycle: cycle/a -> cycle/b -> cycle/a",
+		},
+		{
+			name:         "Unknown request",
+			request:      broken,
+			expectingErr: "broken depends on auth/missing: request not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				actual, err := request.Dependencies(groups, tc.request)
+				if tc.expectingErr != "" {
+					assert.EqualError(t, err, tc.expectingErr)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, actual)
+			},
+		)
+	}
+}
@@ -26,8 +26,17 @@ const UnsortedName = "unsorted"
 
 type Group struct {
 	Name     string     `json:"name"`
-	Desc     string     `json:"desc"`
+	Desc     string     `json:"desc,omitempty"`
 	Requests []*Request `json:"requests"`
+	// Defaults are merged into the requests of the group when they are sent.
+	Defaults *Defaults `json:"defaults,omitempty"`
+	// Groups are the groups nested in this one.
+	Groups []*Group `json:"groups,omitempty"`
+	// Path is the file the group was loaded from, and is saved to. It is empty for groups nested in a file, which are
+	// saved with the file, and for directories.
+	Path string `json:"-"`
+
+	parent *Group
 }
 
 func NewGroup(name string) *Group {
@@ -64,6 +73,39 @@ func (group *Group) ListItems() []list.Item {
 	return items
 }
 
+// Parent returns the group this one is nested in, if any.
+func (group *Group) Parent() *Group {
+	return group.parent
+}
+
+// FullName returns the names of the group and every group it is nested in, separated by slashes, e.g. "shop/orders".
+func (group *Group) FullName() string {
+	if group.parent == nil {
+		return group.Name
+	}
+
+	return group.parent.FullName() + "/" + group.Name
+}
+
+// link sets the parent of every group nested in the group.
+func (group *Group) link() {
+	for _, child := range group.Groups {
+		child.parent = group
+		child.link()
+	}
+}
+
+// All returns groups and every group nested in them, each group followed by its children.
+func All(groups []*Group) []*Group {
+	var all []*Group
+	for _, group := range groups {
+		all = append(all, group)
+		all = append(all, All(group.Groups)...)
+	}
+
+	return all
+}
+
 func (group *Group) AddRequest(r *Request) {
 	group.Requests = append(group.Requests, r)
 }
@@ -19,7 +19,6 @@
 package request
 
 import (
-	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -29,33 +28,68 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
-func LoadFrom(dataDir string) ([]*Group, error) {
+// LoadFrom loads the request groups in the requests directory of dataDir. Each YAML file is a group, and each
+// subdirectory a group of the groups within it. Files that can't be loaded are skipped and returned as warnings, so one
+// invalid file doesn't prevent the rest of the collection from being used.
+func LoadFrom(dataDir string) ([]*Group, []error, error) {
 	entries, err := os.ReadDir(dataDir)
 	if err != nil {
-		return nil, fmt.Errorf("reading data directory: %w", err)
+		return nil, nil, fmt.Errorf("reading data directory: %w", err)
 	}
 
 	requestDir, err := findRequestDir(entries)
 	if err != nil {
-		return nil, errors.New("requests directory not found")
+		return nil, nil, fmt.Errorf("requests directory not found: %w", err)
 	}
 
-	files, err := os.ReadDir(path.Join(dataDir, requestDir))
+	return loadDir(path.Join(dataDir, requestDir))
+}
+
+func loadDir(dir string) ([]*Group, []error, error) {
+	files, err := os.ReadDir(dir)
 	if err != nil {
-		return nil, fmt.Errorf("reading requests directory: %w", err)
+		return nil, nil, fmt.Errorf("reading requests directory: %w", err)
 	}
 
-	groups := make([]*Group, 0)
+	var (
+		groups   = make([]*Group, 0)
+		warnings []error
+	)
 	for _, file := range files {
-		group, err := loadRequests(path.Join(dataDir, requestDir, file.Name()))
+		filepath := path.Join(dir, file.Name())
+		if strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+
+		if file.IsDir() {
+			children, childWarnings, err := loadDir(filepath)
+			warnings = append(warnings, childWarnings...)
+			if err != nil {
+				warnings = append(warnings, err)
+				continue
+			}
+
+			group := NewGroup(file.Name())
+			group.Groups = children
+			group.link()
+			groups = append(groups, group)
+			continue
+		}
+
+		if ext := path.Ext(file.Name()); ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+
+		group, err := loadRequests(filepath)
 		if err != nil {
-			return nil, fmt.Errorf("loading request: %w", err)
+			warnings = append(warnings, fmt.Errorf("skipped %s: %w", filepath, err))
+			continue
 		}
 
 		groups = append(groups, group)
 	}
 
-	return groups, nil
+	return groups, warnings, nil
 }
 
 func findRequestDir(entries []fs.DirEntry) (string, error) {
@@ -78,6 +112,19 @@ func loadRequests(filepath string) (*Group, error) {
 	if err = yaml.Unmarshal(body, g); err != nil {
 		return nil, fmt.Errorf("parsing file: %w", err)
 	}
+	g.Path = filepath
+	if g.Name == "" {
+		g.Name = strings.TrimSuffix(path.Base(filepath), path.Ext(filepath))
+	}
+	g.link()
+	for _, group := range All([]*Group{g}) {
+		for _, r := range group.Requests {
+			// aliases are saved back under their usual name, while unknown protocols are kept to be reported on send
+			if r.Data != nil {
+				r.Data.Proto, _ = NormalizeProto(r.Data.Proto)
+			}
+		}
+	}
 
 	return g, nil
 }
@@ -0,0 +1,72 @@
+package request_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+func TestLoadFrom(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"requests/users.yaml": "name: users\nrequests:\n  - name: list\n    data:\n      proto: http/2.0\n",
+		"requests/shop/orders.yaml": `name: orders
+requests:
+  - name: create
+groups:
+  - name: items
+    requests:
+      - name: add
+        depends_on: [shop/orders/create]
+`,
+		"requests/shop/invalid.yaml":    "name: [invalid\n",
+		"requests/shop/.orders.yaml.1":  "ignored",
+		"requests/shop/notes.txt":       "ignored",
+		"requests/untitled.yml":         "requests: []\n",
+		"requests/shop/empty/.keep":     "",
+		"requests/shop/empty/README.md": "",
+	}
+	for name, body := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(body), 0644))
+	}
+
+	groups, warnings, err := request.LoadFrom(dir)
+	require.NoError(t, err)
+
+	require.Len(t, warnings, 1)
+	assert.ErrorContains(t, warnings[0], "invalid.yaml")
+
+	var names []string
+	for _, g := range request.All(groups) {
+		names = append(names, g.FullName())
+	}
+	assert.Equal(t, []string{"shop", "shop/empty", "shop/orders", "shop/orders/items", "untitled", "users"}, names)
+
+	// protocol aliases are loaded under their usual name
+	assert.Equal(t, request.ProtoHTTP2, groups[2].Requests[0].Data.Proto)
+
+	orders := groups[0].Groups[1]
+	items := orders.Groups[0]
+	assert.Same(t, orders, items.Parent())
+
+	deps, err := request.Dependencies(groups, items.Requests[0])
+	require.NoError(t, err)
+	assert.Equal(t, []*request.Request{orders.Requests[0]}, deps)
+
+	// nested groups are saved to the file they are defined in
+	items.Requests[0].Desc = "adds an item"
+	require.NoError(t, items.Save())
+
+	body, err := os.ReadFile(filepath.Join(dir, "requests/shop/orders.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "desc: adds an item")
+
+	// directories have no file of their own
+	assert.Error(t, groups[0].Save())
+}
@@ -0,0 +1,55 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package request
+
+import "strings"
+
+// The protocols a request can be sent with.
+const (
+	// ProtoDefault uses HTTP/2 if the server offers it over TLS, and HTTP/1.1 otherwise.
+	ProtoDefault = ""
+	ProtoHTTP10  = "HTTP/1.0"
+	ProtoHTTP11  = "HTTP/1.1"
+	// ProtoHTTP2 requires HTTP/2 to be negotiated with ALPN over TLS.
+	ProtoHTTP2 = "HTTP/2"
+	// ProtoH2C sends HTTP/2 over a cleartext connection without upgrading to it first, i.e. with prior knowledge.
+	ProtoH2C = "h2c"
+)
+
+// Protos lists every protocol, in the order they are offered in the editor.
+var Protos = []string{ProtoDefault, ProtoHTTP10, ProtoHTTP11, ProtoHTTP2, ProtoH2C}
+
+// NormalizeProto returns the protocol proto names, one of Protos. Names are case-insensitive, and HTTP/2 may also be
+// written as HTTP/2.0 or h2. Unknown protocols are returned unchanged, with false.
+func NormalizeProto(proto string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(proto)) {
+	case "":
+		return ProtoDefault, true
+	case "http/1.0":
+		return ProtoHTTP10, true
+	case "http/1.1":
+		return ProtoHTTP11, true
+	case "http/2", "http/2.0", "h2":
+		return ProtoHTTP2, true
+	case "h2c":
+		return ProtoH2C, true
+	default:
+		return proto, false
+	}
+}
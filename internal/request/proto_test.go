@@ -0,0 +1,40 @@
+package request_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+func TestNormalizeProto(t *testing.T) {
+	testCases := []struct {
+		name     string
+		proto    string
+		expected string
+		valid    bool
+	}{
+		{name: "Default", proto: "", expected: request.ProtoDefault, valid: true},
+		{name: "HTTP/1.0", proto: "HTTP/1.0", expected: request.ProtoHTTP10, valid: true},
+		{name: "Lowercase HTTP/1.1", proto: "http/1.1", expected: request.ProtoHTTP11, valid: true},
+		{name: "HTTP/2", proto: "HTTP/2", expected: request.ProtoHTTP2, valid: true},
+		{name: "HTTP/2.0", proto: "HTTP/2.0", expected: request.ProtoHTTP2, valid: true},
+		{name: "h2", proto: "h2", expected: request.ProtoHTTP2, valid: true},
+		{name: "Uppercase h2c", proto: "H2C", expected: request.ProtoH2C, valid: true},
+		{name: "Surrounding spaces", proto: " h2c ", expected: request.ProtoH2C, valid: true},
+		{name: "Unknown", proto: "HTTP/3", expected: "HTTP/3"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				proto, ok := request.NormalizeProto(tc.proto)
+				assert.Equal(t, tc.expected, proto)
+				assert.Equal(t, tc.valid, ok)
+			},
+		)
+	}
+}
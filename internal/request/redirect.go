@@ -0,0 +1,77 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package request
+
+// DefaultMaxRedirects is how many redirects are followed when a request doesn't set a limit.
+const DefaultMaxRedirects = 10
+
+// Redirects controls how the redirects a request receives are handled. Without it, up to DefaultMaxRedirects redirects
+// are followed the way browsers do.
+type Redirects struct {
+	// Follow sends the request on to the location of a redirect. If it is false, the redirect itself is the response.
+	Follow *bool `json:"follow,omitempty"`
+	// Max is the number of redirects followed before giving up. Zero means DefaultMaxRedirects.
+	Max int `json:"max,omitempty"`
+	// PreserveMethod keeps the method and body of the request for 301, 302 and 303 redirects, instead of changing it
+	// to a GET without a body.
+	PreserveMethod bool `json:"preserve_method,omitempty"`
+	// PreserveAuth keeps the credentials and Authorization header of the request when it is redirected to a different
+	// origin. They are only sent to the origin of the request otherwise.
+	PreserveAuth bool `json:"preserve_auth,omitempty"`
+}
+
+// Following reports whether redirects are followed. A nil Redirects follows them.
+func (r *Redirects) Following() bool {
+	return r == nil || r.Follow == nil || *r.Follow
+}
+
+// Limit returns the number of redirects followed before giving up.
+func (r *Redirects) Limit() int {
+	if r == nil || r.Max <= 0 {
+		return DefaultMaxRedirects
+	}
+
+	return r.Max
+}
+
+// Clone returns a copy of r.
+func (r *Redirects) Clone() *Redirects {
+	if r == nil {
+		return nil
+	}
+
+	c := *r
+	if r.Follow != nil {
+		follow := *r.Follow
+		c.Follow = &follow
+	}
+
+	return &c
+}
+
+// Hop is a redirect received on the way to a response.
+type Hop struct {
+	Method     string `json:"method"`
+	URL        string `json:"url"`
+	Status     string `json:"status"`
+	StatusCode int    `json:"status_code"`
+	// Location is the value of the Location header, as sent by the server.
+	Location string `json:"location,omitempty"`
+	Timing   Timing `json:"timing"`
+}
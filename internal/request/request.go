@@ -20,21 +20,37 @@ package request
 
 import (
 	"reflect"
+	"strings"
 )
 
 type Request struct {
 	Name string `json:"name,omitempty"`
 	Desc string `json:"desc,omitempty"`
 	Data *Data  `json:"data,omitempty"`
+	// Captures write values from the response into variables after the request has been sent.
+	Captures []Capture `json:"captures,omitempty"`
+	// DependsOn references the requests that have to be sent before this one, as "group/name" or by name alone within
+	// the same group.
+	DependsOn []string `json:"depends_on,omitempty"`
 }
 
 type Data struct {
-	URL      string              `json:"url,omitempty"`
-	Headers  map[string][]string `json:"headers,omitempty"`
-	Method   string              `json:"method,omitempty"`
-	Proto    string              `json:"proto,omitempty"`
-	Body     string              `json:"body,omitempty"`
-	Response *Data               `json:"response,omitempty"`
+	URL     string              `json:"url,omitempty"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Method  string              `json:"method,omitempty"`
+	// Proto is the protocol the request is sent with, one of Protos.
+	Proto string `json:"proto,omitempty"`
+	Body  string `json:"body,omitempty"`
+	// DisabledHeaders are headers that are kept with the request but not sent.
+	DisabledHeaders map[string][]string `json:"disabled_headers,omitempty"`
+	Auth            *Auth               `json:"auth,omitempty"`
+	// Timeout limits how long the request may take, including reading the response body. Zero means no limit.
+	Timeout Duration `json:"timeout,omitempty"`
+	// NoCookies sends the request without the cookies of the environment, and ignores the cookies its response sets.
+	NoCookies bool `json:"no_cookies,omitempty"`
+	// Redirects controls whether and how redirects are followed.
+	Redirects *Redirects `json:"redirects,omitempty"`
+	Response  *Response  `json:"response,omitempty"`
 }
 
 // FilterValue is the value we use when filtering against this item when
@@ -48,9 +64,46 @@ func (request *Request) Title() string {
 }
 
 func (request *Request) Description() string {
-	return request.Desc
+	if len(request.DependsOn) == 0 {
+		return request.Desc
+	}
+
+	deps := "depends on " + strings.Join(request.DependsOn, ", ")
+	if request.Desc == "" {
+		return deps
+	}
+
+	return request.Desc + " · " + deps
 }
 
 func (r *Request) Equal(other *Request) bool {
 	return reflect.DeepEqual(r, other)
 }
+
+// Clone returns a copy of the request data that can be modified without affecting d.
+func (d *Data) Clone() *Data {
+	if d == nil {
+		return nil
+	}
+
+	c := *d
+	c.Headers = cloneHeaders(d.Headers)
+	c.DisabledHeaders = cloneHeaders(d.DisabledHeaders)
+	c.Auth = d.Auth.Clone()
+	c.Redirects = d.Redirects.Clone()
+
+	return &c
+}
+
+func cloneHeaders(headers map[string][]string) map[string][]string {
+	if headers == nil {
+		return nil
+	}
+
+	c := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		c[name] = append([]string(nil), values...)
+	}
+
+	return c
+}
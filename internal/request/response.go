@@ -0,0 +1,112 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package request
+
+import (
+	"net/http"
+	"time"
+)
+
+// Response is the result of sending a Request.
+type Response struct {
+	Status     string              `json:"status,omitempty"`
+	StatusCode int                 `json:"status_code,omitempty"`
+	Proto      string              `json:"proto,omitempty"`
+	Headers    map[string][]string `json:"headers,omitempty"`
+	Body       []byte              `json:"body,omitempty"`
+	Timing     Timing              `json:"timing"`
+	// RawRequest is the request line, headers and body as they were written to the server.
+	RawRequest string `json:"raw_request,omitempty"`
+	// RawHeaders is the status line and headers as they were received from the server.
+	RawHeaders string `json:"raw_headers,omitempty"`
+	// TLS describes the TLS connection the response was received over, if there was one.
+	TLS *TLSInfo `json:"tls,omitempty"`
+	// Redirects are the redirects followed to get the response, in the order they were received.
+	Redirects []Hop `json:"redirects,omitempty"`
+}
+
+// TLSInfo describes a TLS connection.
+type TLSInfo struct {
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipher_suite"`
+	ServerName  string `json:"server_name,omitempty"`
+	// Protocol is the application protocol negotiated with ALPN, e.g. "h2".
+	Protocol string `json:"protocol,omitempty"`
+	// Certificates is the chain presented by the server, starting with its own certificate.
+	Certificates []Certificate `json:"certificates,omitempty"`
+}
+
+// Certificate describes an X.509 certificate.
+type Certificate struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	DNSNames  []string  `json:"dns_names,omitempty"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+// Timing records when a request was sent and how long each phase of it took to complete. Phases that did not happen,
+// such as DNS resolution when a connection was reused, are zero.
+type Timing struct {
+	Start time.Time `json:"start"`
+	// DNS is the time spent resolving the host name.
+	DNS time.Duration `json:"dns,omitempty"`
+	// Connect is the time spent establishing the TCP connection.
+	Connect time.Duration `json:"connect,omitempty"`
+	// TLS is the time spent on the TLS handshake.
+	TLS time.Duration `json:"tls,omitempty"`
+	// Send is the time from having a connection to the request having been written.
+	Send time.Duration `json:"send,omitempty"`
+	// Wait is the time from the request having been written to the first byte of the response, i.e. time to first
+	// byte.
+	Wait time.Duration `json:"wait,omitempty"`
+	// Transfer is the time spent reading the response body.
+	Transfer time.Duration `json:"transfer,omitempty"`
+	Total    time.Duration `json:"total"`
+	// Reused reports whether an idle connection was reused for the request.
+	Reused bool `json:"reused,omitempty"`
+}
+
+// Phase is a named duration within a Timing.
+type Phase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Phases returns each phase of t in the order they happen.
+func (t Timing) Phases() []Phase {
+	return []Phase{
+		{Name: "DNS", Duration: t.DNS},
+		{Name: "Connect", Duration: t.Connect},
+		{Name: "TLS", Duration: t.TLS},
+		{Name: "Send", Duration: t.Send},
+		{Name: "Wait", Duration: t.Wait},
+		{Name: "Transfer", Duration: t.Transfer},
+	}
+}
+
+// Cookies parses the Set-Cookie headers of the response.
+func (r *Response) Cookies() []*http.Cookie {
+	return (&http.Response{Header: r.Headers}).Cookies()
+}
+
+// ContentType returns the value of the Content-Type header of the response.
+func (r *Response) ContentType() string {
+	return http.Header(r.Headers).Get("Content-Type")
+}
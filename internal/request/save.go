@@ -0,0 +1,361 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/cstaaben/go-rest/internal/fileutil"
+)
+
+// Clone returns a copy of r that can be modified without affecting it. The last response is not copied.
+func (r *Request) Clone() *Request {
+	c := *r
+	c.Captures = slices.Clone(r.Captures)
+	c.DependsOn = slices.Clone(r.DependsOn)
+
+	if r.Data != nil {
+		c.Data = r.Data.Clone()
+		c.Data.Response = nil
+	}
+
+	return &c
+}
+
+// Save writes the group to the file it was loaded from, creating it if necessary. An existing file is updated in place
+// rather than replaced, so the order of keys and any comments are kept for values that still exist.
+// Nested groups are saved with the file they are defined in.
+func (group *Group) Save() error {
+	if group.Path == "" {
+		if group.parent != nil {
+			return group.parent.Save()
+		}
+
+		return errors.New("group has no file")
+	}
+
+	// JSON keeps the order of the struct fields, and is valid YAML
+	body, err := json.Marshal(group.persisted())
+	if err != nil {
+		return fmt.Errorf("encoding group: %w", err)
+	}
+
+	var doc yamlv3.Node
+	if err = yamlv3.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("encoding group: %w", err)
+	}
+	plainStyle(&doc)
+
+	existing, err := os.ReadFile(group.Path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if err = os.MkdirAll(filepath.Dir(group.Path), 0755); err != nil {
+			return fmt.Errorf("creating directory: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("reading file: %w", err)
+	default:
+		var old yamlv3.Node
+		// a file that can't be parsed is replaced entirely
+		if yamlv3.Unmarshal(existing, &old) == nil && len(old.Content) > 0 {
+			mergeNode(old.Content[0], doc.Content[0])
+			doc = old
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err = enc.Encode(&doc); err != nil {
+		return fmt.Errorf("encoding group: %w", err)
+	}
+	if err = enc.Close(); err != nil {
+		return fmt.Errorf("encoding group: %w", err)
+	}
+
+	return fileutil.WriteAtomic(group.Path, buf.Bytes(), 0644)
+}
+
+// persisted returns a copy of the group and every group nested in it without the responses of their requests, which
+// are only kept for the session and never written to the collection.
+func (group *Group) persisted() *Group {
+	c := *group
+	c.Requests = make([]*Request, 0, len(group.Requests))
+	for _, r := range group.Requests {
+		c.Requests = append(c.Requests, r.Clone())
+	}
+
+	if group.Groups != nil {
+		c.Groups = make([]*Group, 0, len(group.Groups))
+		for _, child := range group.Groups {
+			c.Groups = append(c.Groups, child.persisted())
+		}
+	}
+
+	return &c
+}
+
+// Add adds r to the group and saves it.
+func (group *Group) Add(r *Request) error {
+	if err := group.checkName(r.Name, nil); err != nil {
+		return err
+	}
+
+	group.AddRequest(r)
+
+	if err := group.Save(); err != nil {
+		group.RemoveRequest(r)
+		return err
+	}
+
+	return nil
+}
+
+// SaveAs adds a copy of r named name to the group and saves it.
+func (group *Group) SaveAs(r *Request, name string) (*Request, error) {
+	if err := group.checkName(name, nil); err != nil {
+		return nil, err
+	}
+
+	c := r.Clone()
+	c.Name = name
+	group.AddRequest(c)
+
+	if err := group.Save(); err != nil {
+		group.RemoveRequest(c)
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Rename changes the name of r, which belongs to the group, and saves the group. The references to r in the
+// dependencies of requests in groups are changed to the new name, and the groups of those requests saved as well.
+func (group *Group) Rename(groups []*Group, r *Request, name string) error {
+	if err := group.checkName(name, r); err != nil {
+		return err
+	}
+
+	// references are resolved before renaming, since they point at the old name
+	type reference struct {
+		request *Request
+		idx     int
+		old     string
+	}
+	var (
+		refs    []reference
+		changed = []*Group{group}
+	)
+	for _, g := range All(groups) {
+		for _, req := range g.Requests {
+			for i, ref := range req.DependsOn {
+				if dep, _, err := find(groups, g.FullName(), ref); err != nil || dep != r {
+					continue
+				}
+
+				refs = append(refs, reference{request: req, idx: i, old: ref})
+				if !slices.Contains(changed, g) {
+					changed = append(changed, g)
+				}
+			}
+		}
+	}
+
+	old := r.Name
+	r.Name = name
+	for _, ref := range refs {
+		renamed := name
+		if i := strings.LastIndex(ref.old, "/"); i >= 0 {
+			renamed = ref.old[:i+1] + name
+		}
+		ref.request.DependsOn[ref.idx] = renamed
+	}
+
+	for _, g := range changed {
+		if err := g.Save(); err != nil {
+			r.Name = old
+			for _, ref := range refs {
+				ref.request.DependsOn[ref.idx] = ref.old
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Duplicate adds a copy of r, which belongs to the group, right after it and saves the group.
+func (group *Group) Duplicate(r *Request) (*Request, error) {
+	c := r.Clone()
+	c.Name = r.Name + " copy"
+	for i := 2; group.checkName(c.Name, nil) != nil; i++ {
+		c.Name = fmt.Sprintf("%s copy %d", r.Name, i)
+	}
+
+	idx := slices.Index(group.Requests, r) + 1
+	group.Requests = slices.Insert(group.Requests, idx, c)
+
+	if err := group.Save(); err != nil {
+		group.RemoveRequest(c)
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Delete removes r from the group and saves it.
+func (group *Group) Delete(r *Request) error {
+	idx := slices.Index(group.Requests, r)
+	if idx == -1 {
+		return fmt.Errorf("request %s is not in group %s", r.Name, group.Name)
+	}
+
+	group.RemoveRequest(r)
+
+	if err := group.Save(); err != nil {
+		group.Requests = slices.Insert(group.Requests, idx, r)
+		return err
+	}
+
+	return nil
+}
+
+// checkName returns an error if name can't be used for a request in the group, other than self. Names have to be
+// unique, since requests reference their dependencies by name.
+func (group *Group) checkName(name string, self *Request) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("request name is empty")
+	}
+
+	for _, r := range group.Requests {
+		if r != self && r.Name == name {
+			return fmt.Errorf("a request named %s already exists in %s", name, group.Name)
+		}
+	}
+
+	return nil
+}
+
+// plainStyle resets the style of node and its children, so values decoded from JSON are written in block style
+// without quotes where possible.
+func plainStyle(node *yamlv3.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		plainStyle(child)
+	}
+}
+
+// mergeNode updates dst to have the values of src, keeping the comments and key order of dst wherever a value exists
+// in both.
+func mergeNode(dst, src *yamlv3.Node) {
+	if dst.Kind != src.Kind {
+		head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+		*dst = *src
+		dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+		return
+	}
+
+	switch dst.Kind {
+	case yamlv3.MappingNode:
+		content := make([]*yamlv3.Node, 0, len(src.Content))
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			if value := mappingValue(src, dst.Content[i].Value); value != nil {
+				mergeNode(dst.Content[i+1], value)
+				content = append(content, dst.Content[i], dst.Content[i+1])
+			}
+		}
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			if mappingValue(dst, src.Content[i].Value) == nil {
+				content = append(content, src.Content[i], src.Content[i+1])
+			}
+		}
+		dst.Content = content
+	case yamlv3.SequenceNode:
+		content := make([]*yamlv3.Node, 0, len(src.Content))
+		used := make(map[*yamlv3.Node]bool, len(dst.Content))
+		for i, item := range src.Content {
+			if match := matchItem(dst, src, item, i, used); match != nil {
+				used[match] = true
+				mergeNode(match, item)
+				content = append(content, match)
+				continue
+			}
+			content = append(content, item)
+		}
+		dst.Content = content
+	case yamlv3.ScalarNode:
+		if dst.Tag != src.Tag || strings.Contains(src.Value, "\n") {
+			dst.Style = src.Style
+		}
+		dst.Tag = src.Tag
+		dst.Value = src.Value
+	default:
+		*dst = *src
+	}
+}
+
+// mappingValue returns the value of key in the mapping node, or nil if it doesn't have it.
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// matchItem finds the element of the sequence dst that corresponds to item, the element at idx of the sequence src.
+// Mappings with a name, such as requests, are matched by it so reordering and removing elements keeps their comments.
+// Anything else, or a renamed element, is matched by position.
+func matchItem(dst, src, item *yamlv3.Node, idx int, used map[*yamlv3.Node]bool) *yamlv3.Node {
+	name := mappingValue(item, "name")
+	if item.Kind == yamlv3.MappingNode && name != nil {
+		for _, candidate := range dst.Content {
+			if other := mappingValue(candidate, "name"); !used[candidate] && other != nil && other.Value == name.Value {
+				return candidate
+			}
+		}
+	}
+
+	if idx >= len(dst.Content) || used[dst.Content[idx]] {
+		return nil
+	}
+
+	// the element at the same position is only reused if it doesn't still exist under its own name
+	candidate := dst.Content[idx]
+	if other := mappingValue(candidate, "name"); other != nil && name != nil {
+		for _, s := range src.Content {
+			if n := mappingValue(s, "name"); n != nil && n.Value == other.Value {
+				return nil
+			}
+		}
+	}
+
+	return candidate
+}
@@ -0,0 +1,220 @@
+package request_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+const collection = `# orders API
+name: orders
+requests:
+  # creates an order
+  - name: create
+    data:
+      method: POST # always POST
+      url: https://example.com/orders
+  - name: get
+    data:
+      url: https://example.com/orders/1
+`
+
+func loadGroup(t *testing.T) *request.Group {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "requests"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "requests", "orders.yaml"), []byte(collection), 0644))
+
+	groups, warnings, err := request.LoadFrom(dir)
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+	require.Len(t, groups, 1)
+
+	return groups[0]
+}
+
+func readGroup(t *testing.T, g *request.Group) string {
+	t.Helper()
+
+	body, err := os.ReadFile(g.Path)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestGroup_Save(t *testing.T) {
+	g := loadGroup(t)
+
+	// unchanged content is written back as it was
+	require.NoError(t, g.Save())
+	assert.Equal(t, collection, readGroup(t, g))
+
+	g.Requests[0].Data.URL = "https://example.com/v2/orders"
+	g.Requests[1].Data.Response = &request.Response{StatusCode: 200}
+	require.NoError(t, g.Save())
+	assert.Equal(t, `# orders API
+name: orders
+requests:
+  # creates an order
+  - name: create
+    data:
+      method: POST # always POST
+      url: https://example.com/v2/orders
+  - name: get
+    data:
+      url: https://example.com/orders/1
+`, readGroup(t, g))
+}
+
+func TestGroup_SaveNested(t *testing.T) {
+	body := `name: shop
+requests:
+  - name: health
+    data:
+      url: https://example.com/health
+groups:
+  - name: orders
+    requests:
+      - name: get
+        data:
+          url: https://example.com/orders/1
+    groups:
+      - name: admin
+        requests:
+          - name: delete
+            data:
+              method: DELETE
+              url: https://example.com/orders/1
+`
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "requests"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "requests", "shop.yaml"), []byte(body), 0644))
+
+	groups, warnings, err := request.LoadFrom(dir)
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+	require.Len(t, groups, 1)
+
+	shop := groups[0]
+	orders := shop.Groups[0]
+	admin := orders.Groups[0]
+
+	response := &request.Response{StatusCode: 200, Body: []byte("secret"), RawRequest: "Authorization: Basic x"}
+	shop.Requests[0].Data.Response = response
+	orders.Requests[0].Data.Response = response
+	admin.Requests[0].Data.Response = response
+
+	// saving a nested group writes the file it is defined in, without any of the responses
+	require.NoError(t, admin.Save())
+	assert.Equal(t, body, readGroup(t, shop))
+
+	// the responses are still kept for the session
+	assert.Same(t, response, admin.Requests[0].Data.Response)
+}
+
+func TestGroup_RenameDependents(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"orders.yaml": `name: orders
+requests:
+  - name: create
+  - name: get
+    depends_on: [create]
+groups:
+  - name: items
+    requests:
+      - name: add
+        depends_on: [orders/create, get]
+`,
+		"users.yaml": `name: users
+requests:
+  - name: list
+    depends_on: [orders/create, orders/get]
+`,
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "requests"), 0755))
+	for name, body := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "requests", name), []byte(body), 0644))
+	}
+
+	groups, warnings, err := request.LoadFrom(dir)
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+
+	orders, users := groups[0], groups[1]
+	require.NoError(t, orders.Rename(groups, orders.Requests[0], "create order"))
+
+	// references to the request follow the new name, in every file, while other references are left alone
+	assert.Equal(t, `name: orders
+requests:
+  - name: create order
+  - name: get
+    depends_on: [create order]
+groups:
+  - name: items
+    requests:
+      - name: add
+        depends_on: [orders/create order, get]
+`, readGroup(t, orders))
+	assert.Equal(t, `name: users
+requests:
+  - name: list
+    depends_on: [orders/create order, orders/get]
+`, readGroup(t, users))
+
+	deps, err := request.Dependencies(groups, users.Requests[0])
+	require.NoError(t, err)
+	assert.Equal(t, []*request.Request{orders.Requests[0], orders.Requests[1]}, deps)
+}
+
+func TestGroup_Operations(t *testing.T) {
+	g := loadGroup(t)
+	create, get := g.Requests[0], g.Requests[1]
+
+	groups := []*request.Group{g}
+	require.NoError(t, g.Rename(groups, create, "create order"))
+	assert.ErrorContains(t, g.Rename(groups, get, "create order"), "already exists")
+
+	dup, err := g.Duplicate(create)
+	require.NoError(t, err)
+	assert.Equal(t, "create order copy", dup.Name)
+
+	dup, err = g.Duplicate(create)
+	require.NoError(t, err)
+	assert.Equal(t, "create order copy 2", dup.Name)
+
+	require.NoError(t, g.Delete(dup))
+
+	copied, err := g.SaveAs(get, "get v2")
+	require.NoError(t, err)
+	copied.Data.URL = "https://example.com/v2/orders/1"
+	assert.Equal(t, "https://example.com/orders/1", get.Data.URL)
+	require.NoError(t, g.Save())
+
+	assert.Equal(t, `# orders API
+name: orders
+requests:
+  # creates an order
+  - name: create order
+    data:
+      method: POST # always POST
+      url: https://example.com/orders
+  - name: create order copy
+    data:
+      url: https://example.com/orders
+      method: POST
+  - name: get
+    data:
+      url: https://example.com/orders/1
+  - name: get v2
+    data:
+      url: https://example.com/v2/orders/1
+`, readGroup(t, g))
+}
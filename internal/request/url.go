@@ -0,0 +1,65 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package request
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// placeholder matches the {{placeholders}} in a URL, which are left as they are when a query is encoded.
+var placeholder = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+
+// SplitURL splits rawURL into the part before the query, the query without its leading "?", and the fragment
+// including its leading "#". The URL isn't parsed, since it can contain placeholders that aren't valid in a URL.
+func SplitURL(rawURL string) (base, query, fragment string) {
+	base = rawURL
+	if i := strings.Index(base, "#"); i >= 0 {
+		base, fragment = base[:i], base[i:]
+	}
+
+	base, query, _ = strings.Cut(base, "?")
+	return base, query, fragment
+}
+
+// EscapeQuery escapes s for use in a query, leaving any placeholders in it untouched.
+func EscapeQuery(s string) string {
+	var sb strings.Builder
+
+	last := 0
+	for _, loc := range placeholder.FindAllStringIndex(s, -1) {
+		sb.WriteString(url.QueryEscape(s[last:loc[0]]))
+		sb.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	sb.WriteString(url.QueryEscape(s[last:]))
+
+	return sb.String()
+}
+
+// UnescapeQuery decodes s, returning it as it is if it isn't validly escaped.
+func UnescapeQuery(s string) string {
+	unescaped, err := url.QueryUnescape(s)
+	if err != nil {
+		return s
+	}
+
+	return unescaped
+}
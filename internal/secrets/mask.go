@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Masked is what secret values are replaced with by Mask.
+const Masked = "********"
+
+// shortLength is the length below which secret values are only masked where they make up a whole word, so a secret
+// of a character or two doesn't hide every occurrence of those characters.
+const shortLength = 4
+
+var (
+	mu     sync.RWMutex
+	values []string
+)
+
+// register adds value to the values hidden by Mask.
+func register(value string) {
+	if value == "" {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, v := range values {
+		if v == value {
+			return
+		}
+	}
+
+	values = append(values, value)
+	// longer values are replaced first, so a secret containing a shorter one is still masked completely
+	slices.SortFunc(values, func(a, b string) int {
+		return len(b) - len(a)
+	})
+}
+
+// Mask replaces every secret value that has been loaded from or stored in a vault in s with Masked.
+func Mask(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, v := range values {
+		if len(v) < shortLength {
+			s = replaceWords(s, v)
+			continue
+		}
+
+		s = strings.ReplaceAll(s, v, Masked)
+	}
+
+	return s
+}
+
+// replaceWords replaces the occurrences of v in s with Masked, unless they are part of a longer word.
+func replaceWords(s, v string) string {
+	var sb strings.Builder
+
+	written := 0
+	for offset := 0; ; {
+		i := strings.Index(s[offset:], v)
+		if i == -1 {
+			break
+		}
+
+		start, end := offset+i, offset+i+len(v)
+		if !isWordChar(s, start-1) && !isWordChar(s, end) {
+			sb.WriteString(s[written:start])
+			sb.WriteString(Masked)
+			written = end
+		}
+		offset = end
+	}
+	sb.WriteString(s[written:])
+
+	return sb.String()
+}
+
+// isWordChar reports whether the byte of s at i is a letter, digit or underscore.
+func isWordChar(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+
+	c := s[i]
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,227 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Package secrets stores secret values, such as tokens, in an encrypted file so they never have to be written to
+// environment files in plain text.
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/cstaaben/go-rest/internal/fileutil"
+)
+
+const (
+	vaultVersion = 1
+	saltSize     = 16
+	keySize      = 32
+	// scrypt parameters, as recommended for interactive logins
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// PassphraseEnv is the environment variable the vault passphrase is read from.
+const PassphraseEnv = "GO_REST_VAULT_PASSPHRASE"
+
+var (
+	// ErrWrongPassphrase is returned when a vault can't be decrypted with the given passphrase.
+	ErrWrongPassphrase = errors.New("wrong passphrase or corrupted vault")
+	// ErrLocked is returned when there is no passphrase to unlock the vault with.
+	ErrLocked = errors.New("vault is locked: set " + PassphraseEnv + " or secrets.key_file")
+)
+
+// file is the format of a vault on disk.
+type file struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// Vault is a set of named secrets, encrypted with AES-GCM using a key derived from a passphrase.
+type Vault struct {
+	path    string
+	salt    []byte
+	key     []byte
+	secrets map[string]string
+}
+
+// Unlock opens the vault at path with the passphrase from PassphraseEnv or, if that isn't set, the contents of keyFile.
+// ErrLocked is returned if neither is available.
+func Unlock(path, keyFile string) (*Vault, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return Open(path, []byte(passphrase))
+	}
+
+	if keyFile == "" {
+		return nil, ErrLocked
+	}
+
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading key file: %w", err)
+	}
+
+	return Open(path, bytes.TrimSpace(key))
+}
+
+// Open decrypts the vault at path with passphrase. If the file doesn't exist, an empty vault is returned that will be
+// created when saved.
+func Open(path string, passphrase []byte) (*Vault, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+
+	body, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		salt := make([]byte, saltSize)
+		if _, err = rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("generating salt: %w", err)
+		}
+
+		return newVault(path, salt, passphrase, make(map[string]string))
+	} else if err != nil {
+		return nil, fmt.Errorf("reading vault: %w", err)
+	}
+
+	var f file
+	if err = json.Unmarshal(body, &f); err != nil {
+		return nil, fmt.Errorf("parsing vault: %w", err)
+	}
+
+	if f.Version != vaultVersion {
+		return nil, fmt.Errorf("unsupported vault version %d", f.Version)
+	}
+
+	v, err := newVault(path, f.Salt, passphrase, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := v.cipher()
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	if err = json.Unmarshal(plaintext, &v.secrets); err != nil {
+		return nil, fmt.Errorf("parsing secrets: %w", err)
+	}
+
+	for _, value := range v.secrets {
+		register(value)
+	}
+
+	return v, nil
+}
+
+func newVault(path string, salt, passphrase []byte, secrets map[string]string) (*Vault, error) {
+	key, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key: %w", err)
+	}
+
+	return &Vault{
+		path:    path,
+		salt:    salt,
+		key:     key,
+		secrets: secrets,
+	}, nil
+}
+
+func (v *Vault) cipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(v.key)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Get returns the secret with the given name.
+func (v *Vault) Get(name string) (string, bool) {
+	value, ok := v.secrets[name]
+	return value, ok
+}
+
+// Set stores value under name. The vault must be saved for the change to persist.
+func (v *Vault) Set(name, value string) {
+	v.secrets[name] = value
+	register(value)
+}
+
+// Delete removes the secret with the given name. The vault must be saved for the change to persist.
+func (v *Vault) Delete(name string) {
+	delete(v.secrets, name)
+}
+
+// Names returns the sorted names of every secret in the vault.
+func (v *Vault) Names() []string {
+	names := make([]string, 0, len(v.secrets))
+	for name := range v.secrets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// Save encrypts the vault and writes it to disk. A new nonce is used every time the vault is saved.
+func (v *Vault) Save() error {
+	plaintext, err := json.Marshal(v.secrets)
+	if err != nil {
+		return fmt.Errorf("encoding secrets: %w", err)
+	}
+
+	gcm, err := v.cipher()
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return fmt.Errorf("generating nonce: %w", err)
+	}
+
+	body, err := json.Marshal(file{
+		Version: vaultVersion,
+		Salt:    v.salt,
+		Nonce:   nonce,
+		Data:    gcm.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return fmt.Errorf("encoding vault: %w", err)
+	}
+
+	return fileutil.WriteAtomic(v.path, body, 0600)
+}
@@ -0,0 +1,78 @@
+package secrets_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/secrets"
+)
+
+func TestVault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.vault")
+
+	v, err := secrets.Open(path, []byte("passphrase"))
+	require.NoError(t, err)
+	assert.Empty(t, v.Names())
+
+	v.Set("github_token", "ghp_abc123")
+	v.Set("other", "value")
+	v.Delete("other")
+	require.NoError(t, v.Save())
+
+	body, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "ghp_abc123")
+	assert.NotContains(t, string(body), "github_token")
+
+	reopened, err := secrets.Open(path, []byte("passphrase"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github_token"}, reopened.Names())
+
+	value, ok := reopened.Get("github_token")
+	assert.True(t, ok)
+	assert.Equal(t, "ghp_abc123", value)
+
+	_, err = secrets.Open(path, []byte("wrong"))
+	assert.ErrorIs(t, err, secrets.ErrWrongPassphrase)
+}
+
+func TestUnlock(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secrets.vault")
+	keyFile := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("from-file\n"), 0600))
+
+	t.Setenv(secrets.PassphraseEnv, "")
+	_, err := secrets.Unlock(path, "")
+	assert.ErrorIs(t, err, secrets.ErrLocked)
+
+	v, err := secrets.Unlock(path, keyFile)
+	require.NoError(t, err)
+	v.Set("name", "value")
+	require.NoError(t, v.Save())
+
+	_, err = secrets.Open(path, []byte("from-file"))
+	assert.NoError(t, err)
+
+	t.Setenv(secrets.PassphraseEnv, "from-env")
+	_, err = secrets.Unlock(path, keyFile)
+	assert.ErrorIs(t, err, secrets.ErrWrongPassphrase)
+}
+
+func TestMask(t *testing.T) {
+	v, err := secrets.Open(filepath.Join(t.TempDir(), "secrets.vault"), []byte("passphrase"))
+	require.NoError(t, err)
+
+	v.Set("token", "t0k3n-value")
+	assert.Equal(t, "Authorization: Bearer "+secrets.Masked, secrets.Mask("Authorization: Bearer t0k3n-value"))
+	assert.Equal(t, "nothing to hide", secrets.Mask("nothing to hide"))
+
+	// short secrets are only masked where they are a whole word
+	v.Set("pin", "42")
+	assert.Equal(t, "pin="+secrets.Masked+"&id=1427", secrets.Mask("pin=42&id=1427"))
+	assert.Equal(t, secrets.Masked+" "+secrets.Masked, secrets.Mask("42 42"))
+}
@@ -0,0 +1,183 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package transport
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+)
+
+// proxySchemes are the schemes of the proxies requests can be sent through. HTTP proxies tunnel HTTPS requests with
+// CONNECT, and with socks5h the proxy resolves host names instead of the client.
+var proxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+// Proxy configures the proxy requests are sent through.
+type Proxy struct {
+	// URL is the address of the proxy, e.g. "http://proxy.internal:3128" or "socks5://127.0.0.1:1080". Credentials
+	// can be part of the URL or set separately. If it is empty, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+	// variables are used.
+	URL      string `json:"url,omitempty" mapstructure:"url"`
+	Username string `json:"username,omitempty" mapstructure:"username"`
+	Password string `json:"password,omitempty" mapstructure:"password"`
+	// NoProxy lists the hosts requests are sent to directly. Entries are host names, which also match their subdomains,
+	// wildcards such as "*.internal", IP addresses, or CIDR ranges such as "10.0.0.0/8", optionally followed by a
+	// port. "*" bypasses the proxy for every host.
+	NoProxy []string `json:"no_proxy,omitempty" mapstructure:"no_proxy"`
+	// Direct sends every request without a proxy, ignoring the environment variables. It is a pointer so an
+	// environment can turn it off again.
+	Direct *bool `json:"direct,omitempty" mapstructure:"direct"`
+}
+
+// Merge returns p with the settings of override applied to it.
+func (p Proxy) Merge(override Proxy) Proxy {
+	merged := p
+	if override.URL != "" {
+		merged.URL, merged.Username, merged.Password = override.URL, override.Username, override.Password
+	}
+	if override.NoProxy != nil {
+		merged.NoProxy = override.NoProxy
+	}
+	if override.Direct != nil {
+		merged.Direct = override.Direct
+	}
+
+	return merged
+}
+
+// Func returns the function an *http.Transport uses to choose the proxy of each request.
+func (p Proxy) Func() (func(*http.Request) (*url.URL, error), error) {
+	if p.Direct != nil && *p.Direct {
+		return nil, nil
+	}
+
+	bypass := make([]bypassRule, 0, len(p.NoProxy))
+	for _, entry := range p.NoProxy {
+		rule, err := parseBypassRule(entry)
+		if err != nil {
+			return nil, err
+		}
+		bypass = append(bypass, rule)
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if p.URL != "" {
+		proxyURL, err := url.Parse(p.URL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing proxy URL: %w", err)
+		}
+		if !slices.Contains(proxySchemes, proxyURL.Scheme) || proxyURL.Host == "" {
+			return nil, fmt.Errorf("unsupported proxy URL %s, expected one of the schemes %s", proxyURL.Redacted(),
+				strings.Join(proxySchemes, ", "))
+		}
+
+		if p.Username != "" || p.Password != "" {
+			proxyURL.User = url.UserPassword(p.Username, p.Password)
+		}
+
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		for _, rule := range bypass {
+			if rule.matches(req.URL) {
+				return nil, nil
+			}
+		}
+
+		return proxy(req)
+	}, nil
+}
+
+// bypassRule is a parsed entry of the NoProxy list.
+type bypassRule struct {
+	// host is a host name, which may contain wildcards, if the rule isn't an address or range.
+	host   string
+	prefix netip.Prefix
+	// port only matches requests to that port, if it is set.
+	port string
+}
+
+func parseBypassRule(entry string) (bypassRule, error) {
+	entry = strings.ToLower(strings.TrimSpace(entry))
+
+	if prefix, err := netip.ParsePrefix(entry); err == nil {
+		return bypassRule{prefix: prefix.Masked()}, nil
+	}
+
+	var rule bypassRule
+	if host, port, err := net.SplitHostPort(entry); err == nil {
+		entry, rule.port = host, port
+	}
+
+	if addr, err := netip.ParseAddr(entry); err == nil {
+		rule.prefix = netip.PrefixFrom(addr, addr.BitLen())
+		return rule, nil
+	}
+
+	if _, err := path.Match(entry, ""); err != nil || entry == "" {
+		return bypassRule{}, fmt.Errorf("invalid no_proxy entry %q", entry)
+	}
+	rule.host = entry
+
+	return rule, nil
+}
+
+func (r bypassRule) matches(u *url.URL) bool {
+	if r.port != "" && r.port != port(u) {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if r.prefix.IsValid() {
+		addr, err := netip.ParseAddr(host)
+		return err == nil && r.prefix.Contains(addr.Unmap())
+	}
+
+	switch {
+	case strings.HasPrefix(r.host, "*.") && !strings.Contains(r.host[2:], "*"):
+		// a wildcard only matching a single label would leave out hosts such as a.db.internal for "*.internal"
+		return strings.HasSuffix(host, r.host[1:])
+	case strings.Contains(r.host, "*"):
+		matched, _ := path.Match(r.host, host)
+		return matched
+	case strings.HasPrefix(r.host, "."):
+		return strings.HasSuffix(host, r.host)
+	default:
+		return host == r.host || strings.HasSuffix(host, "."+r.host)
+	}
+}
+
+// port returns the port of u, or the default port of its scheme.
+func port(u *url.URL) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+
+	if u.Scheme == "https" {
+		return "443"
+	}
+
+	return "80"
+}
@@ -0,0 +1,347 @@
+package transport_test
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cstaaben/go-rest/internal/transport"
+)
+
+func TestProxy_Func(t *testing.T) {
+	p := transport.Proxy{
+		URL: "http://proxy.internal:3128",
+		NoProxy: []string{
+			"example.com",
+			".internal",
+			"api-*.corp",
+			"*.db.corp",
+			"10.0.0.0/8",
+			"192.168.1.1",
+			"localhost:8080",
+			"[::1]:9000",
+		},
+	}
+
+	proxy, err := p.Func()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		url     string
+		proxied bool
+	}{
+		{url: "https://example.com/", proxied: false},
+		{url: "https://api.example.com/", proxied: false},
+		{url: "https://notexample.com/", proxied: true},
+		{url: "http://db.internal/", proxied: false},
+		{url: "http://internal/", proxied: true},
+		{url: "http://api-eu.corp/", proxied: false},
+		{url: "http://web.corp/", proxied: true},
+		{url: "http://a.db.corp/", proxied: false},
+		{url: "http://a.b.db.corp/", proxied: false},
+		{url: "http://db.corp/", proxied: true},
+		{url: "http://a.db.internal/", proxied: false},
+		{url: "http://10.20.30.40/", proxied: false},
+		{url: "http://11.0.0.1/", proxied: true},
+		{url: "http://192.168.1.1:8443/", proxied: false},
+		{url: "http://192.168.1.2/", proxied: true},
+		{url: "http://localhost:8080/", proxied: false},
+		{url: "http://localhost/", proxied: true},
+		{url: "http://[::1]:9000/", proxied: false},
+		{url: "http://[::1]:9001/", proxied: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.url, func(t *testing.T) {
+				req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+				require.NoError(t, err)
+
+				proxyURL, err := proxy(req)
+				require.NoError(t, err)
+				if tc.proxied {
+					assert.Equal(t, "http://proxy.internal:3128", proxyURL.String())
+				} else {
+					assert.Nil(t, proxyURL)
+				}
+			},
+		)
+	}
+
+	all, err := transport.Proxy{URL: "socks5://127.0.0.1:1080", NoProxy: []string{"*"}}.Func()
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	proxyURL, err := all(req)
+	require.NoError(t, err)
+	assert.Nil(t, proxyURL)
+
+	direct := true
+	none, err := transport.Proxy{URL: "http://proxy.internal:3128", Direct: &direct}.Func()
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	_, err = transport.Proxy{URL: "ftp://proxy.internal"}.Func()
+	assert.ErrorContains(t, err, "unsupported proxy URL")
+	_, err = transport.Proxy{NoProxy: []string{"[invalid"}}.Func()
+	assert.ErrorContains(t, err, "invalid no_proxy entry")
+}
+
+// httpProxy is a stand-in for an HTTP proxy requiring basic authentication. It answers plain HTTP requests itself and
+// tunnels CONNECT requests to their destination.
+func httpProxy(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	credentials := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass"))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Proxy-Authorization") != credentials {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+
+		if r.Method != http.MethodConnect {
+			_, _ = io.WriteString(w, "proxied "+r.URL.String())
+			return
+		}
+
+		dst, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer dst.Close() // nolint:errcheck
+
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		defer conn.Close() // nolint:errcheck
+
+		_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go io.Copy(dst, conn) // nolint:errcheck
+		_, _ = io.Copy(conn, dst)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// socksProxy is a stand-in for a SOCKS5 proxy requiring username and password authentication. It returns the address
+// the proxy listens on.
+func socksProxy(t *testing.T, connections *atomic.Int32) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	serve := func(conn net.Conn) {
+		defer conn.Close() // nolint:errcheck
+		r := bufio.NewReader(conn)
+
+		read := func(n int) []byte {
+			b := make([]byte, n)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return nil
+			}
+			return b
+		}
+		// readString reads a string prefixed with its length
+		readString := func() (string, bool) {
+			n := read(1)
+			if n == nil {
+				return "", false
+			}
+			b := read(int(n[0]))
+			return string(b), b != nil
+		}
+
+		// greeting, choosing username and password authentication
+		header := read(2)
+		if header == nil || read(int(header[1])) == nil {
+			return
+		}
+		_, _ = conn.Write([]byte{5, 2})
+
+		auth := read(2)
+		if auth == nil {
+			return
+		}
+		user := read(int(auth[1]))
+		pass, ok := readString()
+		if user == nil || !ok {
+			return
+		}
+		if string(user) != "user" || pass != "pass" {
+			_, _ = conn.Write([]byte{1, 1})
+			return
+		}
+		_, _ = conn.Write([]byte{1, 0})
+
+		// connect request
+		req := read(4)
+		if req == nil {
+			return
+		}
+		var host string
+		switch req[3] {
+		case 1:
+			host = net.IP(read(4)).String()
+		case 3:
+			if host, ok = readString(); !ok {
+				return
+			}
+		case 4:
+			host = net.IP(read(16)).String()
+		}
+		portBytes := read(2)
+		if portBytes == nil {
+			return
+		}
+		port := binary.BigEndian.Uint16(portBytes)
+
+		dst, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+		if err != nil {
+			_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+			return
+		}
+		defer dst.Close() // nolint:errcheck
+
+		connections.Add(1)
+		_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+		go io.Copy(dst, r) // nolint:errcheck
+		_, _ = io.Copy(conn, dst)
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestSettings_TransportProxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "direct")
+	}))
+	defer target.Close()
+
+	tlsTarget := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "tunneled")
+	}))
+	defer tlsTarget.Close()
+
+	var proxied, tunneled atomic.Int32
+	httpProxyURL := httpProxy(t, &proxied).URL
+	socksAddr := socksProxy(t, &tunneled)
+
+	insecure := true
+	testCases := []struct {
+		name         string
+		proxy        transport.Proxy
+		url          string
+		expectedBody string
+		expectedCode int
+		via          *atomic.Int32
+	}{
+		{
+			name:         "HTTP proxy",
+			proxy:        transport.Proxy{URL: httpProxyURL, Username: "user", Password: "pass"},
+			url:          target.URL + "/path",
+			expectedBody: "proxied " + target.URL + "/path",
+			via:          &proxied,
+		},
+		{
+			name:         "HTTP proxy with credentials in the URL",
+			proxy:        transport.Proxy{URL: withUser(httpProxyURL, "user", "pass")},
+			url:          target.URL,
+			expectedBody: "proxied " + target.URL + "/",
+			via:          &proxied,
+		},
+		{
+			name:         "HTTP proxy rejecting credentials",
+			proxy:        transport.Proxy{URL: httpProxyURL, Username: "user", Password: "wrong"},
+			url:          target.URL,
+			expectedCode: http.StatusProxyAuthRequired,
+			via:          &proxied,
+		},
+		{
+			name:         "CONNECT tunnel",
+			proxy:        transport.Proxy{URL: httpProxyURL, Username: "user", Password: "pass"},
+			url:          tlsTarget.URL,
+			expectedBody: "tunneled",
+			via:          &proxied,
+		},
+		{
+			name:         "SOCKS5",
+			proxy:        transport.Proxy{URL: "socks5://" + socksAddr, Username: "user", Password: "pass"},
+			url:          target.URL,
+			expectedBody: "direct",
+			via:          &tunneled,
+		},
+		{
+			name:         "Bypassed",
+			proxy:        transport.Proxy{URL: httpProxyURL, NoProxy: []string{"127.0.0.0/8"}},
+			url:          target.URL,
+			expectedBody: "direct",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				before := proxied.Load() + tunneled.Load()
+				var beforeVia int32
+				if tc.via != nil {
+					beforeVia = tc.via.Load()
+				}
+
+				tr, err := transport.Settings{TLS: transport.TLS{Insecure: &insecure}, Proxy: tc.proxy}.Transport()
+				require.NoError(t, err)
+				defer tr.CloseIdleConnections()
+
+				resp, err := (&http.Client{Transport: tr}).Get(tc.url)
+				require.NoError(t, err)
+				defer resp.Body.Close() // nolint:errcheck
+
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+
+				if tc.expectedCode == 0 {
+					tc.expectedCode = http.StatusOK
+				}
+				assert.Equal(t, tc.expectedCode, resp.StatusCode)
+				assert.Equal(t, tc.expectedBody, string(body))
+
+				if tc.via != nil {
+					assert.Greater(t, tc.via.Load(), beforeVia, "sent through the proxy")
+				} else {
+					assert.Equal(t, before, proxied.Load()+tunneled.Load(), "sent directly")
+				}
+			},
+		)
+	}
+}
+
+func withUser(rawURL, username, password string) string {
+	u, _ := url.Parse(rawURL)
+	u.User = url.UserPassword(username, password)
+
+	return u.String()
+}
@@ -0,0 +1,184 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Package transport defines the connection settings requests are sent with, which can be set in the configuration and
+// overridden by each environment.
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// tlsVersions are the TLS versions that can be required, by the names they are configured with.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLS configures how servers are verified and how the client authenticates itself to them.
+type TLS struct {
+	// CAFiles are PEM bundles of certificate authorities trusted in addition to those of the system.
+	CAFiles []string `json:"ca_files,omitempty" mapstructure:"ca_files"`
+	// CertFile and KeyFile are the PEM encoded certificate and key the client authenticates with.
+	CertFile string `json:"cert_file,omitempty" mapstructure:"cert_file"`
+	KeyFile  string `json:"key_file,omitempty" mapstructure:"key_file"`
+	// PKCS12File is a PKCS#12 bundle of the certificate and key the client authenticates with, decrypted with
+	// PKCS12Password. It is an alternative to CertFile and KeyFile.
+	PKCS12File     string `json:"pkcs12_file,omitempty" mapstructure:"pkcs12_file"`
+	PKCS12Password string `json:"pkcs12_password,omitempty" mapstructure:"pkcs12_password"`
+	// MinVersion is the lowest TLS version accepted, one of "1.0", "1.1", "1.2" and "1.3". By default, it is 1.2.
+	MinVersion string `json:"min_version,omitempty" mapstructure:"min_version"`
+	// ServerName overrides the name sent with SNI and verified against the certificate of the server, which is
+	// otherwise the host of the request.
+	ServerName string `json:"server_name,omitempty" mapstructure:"server_name"`
+	// Insecure skips verifying the certificate of the server. It is a pointer so an environment can turn it off again.
+	Insecure *bool `json:"insecure,omitempty" mapstructure:"insecure"`
+}
+
+// Merge returns t with the settings of override applied to it. Certificate authorities are trusted by both, any other
+// setting of override replaces that of t.
+func (t TLS) Merge(override TLS) TLS {
+	merged := t
+	merged.CAFiles = append(append([]string(nil), t.CAFiles...), override.CAFiles...)
+
+	if override.CertFile != "" || override.KeyFile != "" || override.PKCS12File != "" {
+		merged.CertFile, merged.KeyFile = override.CertFile, override.KeyFile
+		merged.PKCS12File, merged.PKCS12Password = override.PKCS12File, override.PKCS12Password
+	}
+	if override.MinVersion != "" {
+		merged.MinVersion = override.MinVersion
+	}
+	if override.ServerName != "" {
+		merged.ServerName = override.ServerName
+	}
+	if override.Insecure != nil {
+		merged.Insecure = override.Insecure
+	}
+
+	return merged
+}
+
+// expandEnv expands environment variables in the paths of t, the same way as the other paths in the configuration.
+func (t TLS) expandEnv() TLS {
+	expanded := t
+	expanded.CAFiles = make([]string, 0, len(t.CAFiles))
+	for _, f := range t.CAFiles {
+		expanded.CAFiles = append(expanded.CAFiles, os.ExpandEnv(f))
+	}
+	expanded.CertFile = os.ExpandEnv(t.CertFile)
+	expanded.KeyFile = os.ExpandEnv(t.KeyFile)
+	expanded.PKCS12File = os.ExpandEnv(t.PKCS12File)
+
+	return expanded
+}
+
+// Config builds the *tls.Config described by t.
+func (t TLS) Config() (*tls.Config, error) {
+	t = t.expandEnv()
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.Insecure != nil && *t.Insecure, // nolint:gosec // explicitly configured by the user
+	}
+
+	if t.MinVersion != "" {
+		version, ok := tlsVersions[strings.TrimPrefix(t.MinVersion, "TLS")]
+		if !ok {
+			return nil, fmt.Errorf("unsupported minimum TLS version %q", t.MinVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if len(t.CAFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		for _, f := range t.CAFiles {
+			pem, err := os.ReadFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("reading CA bundle: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in CA bundle %s", f)
+			}
+		}
+
+		config.RootCAs = pool
+	}
+
+	cert, err := t.certificate()
+	if err != nil {
+		return nil, err
+	}
+	if cert != nil {
+		config.Certificates = []tls.Certificate{*cert}
+	}
+
+	return config, nil
+}
+
+// certificate loads the client certificate, if there is one.
+func (t TLS) certificate() (*tls.Certificate, error) {
+	switch {
+	case t.PKCS12File != "" && (t.CertFile != "" || t.KeyFile != ""):
+		return nil, errors.New("client certificate can't be both a PKCS#12 bundle and PEM files")
+	case t.PKCS12File != "":
+		data, err := os.ReadFile(t.PKCS12File)
+		if err != nil {
+			return nil, fmt.Errorf("reading PKCS#12 bundle: %w", err)
+		}
+
+		key, cert, chain, err := pkcs12.DecodeChain(data, t.PKCS12Password)
+		if err != nil {
+			return nil, fmt.Errorf("decoding PKCS#12 bundle %s: %w", t.PKCS12File, err)
+		}
+
+		// the rest of the chain is sent along, so servers only need to trust the root
+		certificate := &tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key, Leaf: cert}
+		for _, ca := range chain {
+			certificate.Certificate = append(certificate.Certificate, ca.Raw)
+		}
+
+		return certificate, nil
+	case t.CertFile != "" || t.KeyFile != "":
+		if t.CertFile == "" || t.KeyFile == "" {
+			return nil, errors.New("client certificate needs both a cert file and a key file")
+		}
+
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+
+		return &cert, nil
+	default:
+		return nil, nil
+	}
+}
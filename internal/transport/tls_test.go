@@ -0,0 +1,300 @@
+package transport_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"software.sslmate.com/src/go-pkcs12"
+
+	"github.com/cstaaben/go-rest/internal/transport"
+)
+
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+
+	return path
+}
+
+// clientCert creates a self-signed client certificate, returning the paths of its PEM files and the certificate.
+func clientCert(t *testing.T) (string, string, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "go-rest"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return writePEM(t, "client.pem", "CERTIFICATE", der), writePEM(t, "client-key.pem", "EC PRIVATE KEY", keyDER), cert
+}
+
+func TestTLS_Merge(t *testing.T) {
+	on, off := true, false
+	base := transport.TLS{
+		CAFiles:    []string{"base.pem"},
+		CertFile:   "cert.pem",
+		KeyFile:    "key.pem",
+		MinVersion: "1.2",
+		Insecure:   &on,
+	}
+
+	merged := base.Merge(transport.TLS{CAFiles: []string{"env.pem"}, PKCS12File: "client.p12", Insecure: &off})
+	assert.Equal(t, transport.TLS{
+		CAFiles:    []string{"base.pem", "env.pem"},
+		PKCS12File: "client.p12",
+		MinVersion: "1.2",
+		Insecure:   &off,
+	}, merged)
+
+	assert.Equal(t, []string{"base.pem"}, base.CAFiles, "the base settings are left untouched")
+	assert.Equal(t, base, base.Merge(transport.TLS{}))
+}
+
+func TestTLS_Config(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.pem")
+	require.NoError(t, os.WriteFile(empty, nil, 0600))
+	garbage := filepath.Join(dir, "client.p12")
+	require.NoError(t, os.WriteFile(garbage, []byte("not a bundle"), 0600))
+
+	on := true
+
+	testCases := []struct {
+		name        string
+		tls         transport.TLS
+		expected    func(t *testing.T, c *tls.Config)
+		expectedErr string
+	}{
+		{
+			name: "Defaults",
+			expected: func(t *testing.T, c *tls.Config) {
+				assert.Equal(t, uint16(tls.VersionTLS12), c.MinVersion)
+				assert.False(t, c.InsecureSkipVerify)
+				assert.Nil(t, c.RootCAs)
+			},
+		},
+		{
+			name: "Minimum version, server name and insecure",
+			tls:  transport.TLS{MinVersion: "1.3", ServerName: "api.internal", Insecure: &on},
+			expected: func(t *testing.T, c *tls.Config) {
+				assert.Equal(t, uint16(tls.VersionTLS13), c.MinVersion)
+				assert.Equal(t, "api.internal", c.ServerName)
+				assert.True(t, c.InsecureSkipVerify)
+			},
+		},
+		{
+			name:        "Unknown version",
+			tls:         transport.TLS{MinVersion: "1.4"},
+			expectedErr: `unsupported minimum TLS version "1.4"`,
+		},
+		{
+			name:        "Missing CA bundle",
+			tls:         transport.TLS{CAFiles: []string{filepath.Join(dir, "missing.pem")}},
+			expectedErr: "reading CA bundle",
+		},
+		{
+			name:        "Empty CA bundle",
+			tls:         transport.TLS{CAFiles: []string{empty}},
+			expectedErr: "no certificates found",
+		},
+		{
+			name:        "Cert without key",
+			tls:         transport.TLS{CertFile: "cert.pem"},
+			expectedErr: "needs both a cert file and a key file",
+		},
+		{
+			name:        "PEM and PKCS#12",
+			tls:         transport.TLS{CertFile: "cert.pem", KeyFile: "key.pem", PKCS12File: garbage},
+			expectedErr: "can't be both",
+		},
+		{
+			name:        "Invalid PKCS#12 bundle",
+			tls:         transport.TLS{PKCS12File: garbage},
+			expectedErr: "decoding PKCS#12 bundle",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				c, err := tc.tls.Config()
+				if tc.expectedErr != "" {
+					assert.ErrorContains(t, err, tc.expectedErr)
+					return
+				}
+
+				require.NoError(t, err)
+				tc.expected(t, c)
+			},
+		)
+	}
+}
+
+func TestSettings_Transport(t *testing.T) {
+	certFile, keyFile, cert := clientCert(t)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", srv.Certificate().Raw)
+
+	testCases := []struct {
+		name         string
+		tls          transport.TLS
+		expectingErr bool
+	}{
+		{
+			name: "Trusted server with client certificate",
+			// the certificate of the test server is valid for example.com
+			tls: transport.TLS{CAFiles: []string{caFile}, CertFile: certFile, KeyFile: keyFile, ServerName: "example.com"},
+		},
+		{
+			name:         "Untrusted server",
+			tls:          transport.TLS{CertFile: certFile, KeyFile: keyFile},
+			expectingErr: true,
+		},
+		{
+			name:         "Missing client certificate",
+			tls:          transport.TLS{CAFiles: []string{caFile}},
+			expectingErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				tr, err := transport.Settings{TLS: tc.tls}.Transport()
+				require.NoError(t, err)
+				defer tr.CloseIdleConnections()
+
+				resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+				if tc.expectingErr {
+					assert.Error(t, err)
+					return
+				}
+
+				require.NoError(t, err)
+				defer resp.Body.Close() // nolint:errcheck
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, "example.com", resp.TLS.ServerName)
+			},
+		)
+	}
+}
+
+// issue creates a certificate for name signed by parent, or self-signed if parent is nil.
+func issue(
+	t *testing.T, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         isCA,
+
+		BasicConstraintsValid: true,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+func TestSettings_TransportPKCS12(t *testing.T) {
+	root, rootKey := issue(t, "root", true, nil, nil)
+	intermediate, intermediateKey := issue(t, "intermediate", true, root, rootKey)
+	leaf, leafKey := issue(t, "go-rest", false, intermediate, intermediateKey)
+
+	// the server only trusts the root, so the client has to send the intermediate certificate from the bundle
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(root)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+
+	bundle, err := pkcs12.Modern.Encode(leafKey, leaf, []*x509.Certificate{intermediate}, "s3cr3t")
+	require.NoError(t, err)
+	bundleFile := filepath.Join(t.TempDir(), "client.p12")
+	require.NoError(t, os.WriteFile(bundleFile, bundle, 0600))
+
+	insecure := true
+	settings := transport.TLS{PKCS12File: bundleFile, PKCS12Password: "s3cr3t", Insecure: &insecure}
+
+	config, err := settings.Config()
+	require.NoError(t, err)
+	require.Len(t, config.Certificates, 1)
+	assert.Len(t, config.Certificates[0].Certificate, 2)
+
+	tr, err := transport.Settings{TLS: settings}.Transport()
+	require.NoError(t, err)
+	defer tr.CloseIdleConnections()
+
+	resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close() // nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "go-rest", string(body))
+
+	settings.PKCS12Password = "wrong"
+	_, err = settings.Config()
+	assert.ErrorContains(t, err, "decoding PKCS#12 bundle")
+}
@@ -0,0 +1,57 @@
+/*
+ * go-rest - A TUI for a REST client
+ * Copyright (C) 2024  Corbin Staaben
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package transport
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Settings are the settings of the connections requests are sent over.
+type Settings struct {
+	TLS   TLS   `json:"tls,omitempty" mapstructure:"tls"`
+	Proxy Proxy `json:"proxy,omitempty" mapstructure:"proxy"`
+}
+
+// Merge returns s with the settings of override applied to it.
+func (s Settings) Merge(override Settings) Settings {
+	return Settings{
+		TLS:   s.TLS.Merge(override.TLS),
+		Proxy: s.Proxy.Merge(override.Proxy),
+	}
+}
+
+// Transport builds an *http.Transport using s, based on the default transport of net/http.
+func (s Settings) Transport() (*http.Transport, error) {
+	tlsConfig, err := s.TLS.Config()
+	if err != nil {
+		return nil, fmt.Errorf("configuring TLS: %w", err)
+	}
+
+	proxy, err := s.Proxy.Func()
+	if err != nil {
+		return nil, fmt.Errorf("configuring proxy: %w", err)
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsConfig
+	t.Proxy = proxy
+
+	return t, nil
+}
@@ -0,0 +1,15 @@
+package cookies
+
+import (
+	"github.com/cstaaben/go-rest/internal/cookie"
+)
+
+// EditMsg is sent when the user asks to edit the value of a cookie, so the new value can be prompted for.
+type EditMsg struct {
+	Cookie cookie.Cookie
+}
+
+// DeleteMsg is sent when the user asks to delete a cookie, so it can be confirmed first.
+type DeleteMsg struct {
+	Cookie cookie.Cookie
+}
@@ -0,0 +1,181 @@
+// Package cookies defines the model listing the cookies of the selected environment.
+package cookies
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cstaaben/go-rest/internal/cookie"
+	"github.com/cstaaben/go-rest/internal/model/target"
+	"github.com/cstaaben/go-rest/internal/secrets"
+	"github.com/cstaaben/go-rest/internal/ui/environments"
+	"github.com/cstaaben/go-rest/internal/ui/notification"
+	"github.com/cstaaben/go-rest/internal/ui/styles"
+)
+
+const (
+	defaultListWidth  = 80
+	defaultListHeight = 100
+)
+
+var (
+	editKey = key.NewBinding(
+		key.WithKeys(tea.KeyEnter.String(), "e"),
+		key.WithHelp(tea.KeyEnter.String(), "Edit cookie value"),
+	)
+	deleteKey = key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "Delete cookie"),
+	)
+)
+
+// item displays a cookie in the list. Cookies are sorted by domain, so the cookies of each domain are listed together.
+type item struct {
+	cookie cookie.Cookie
+}
+
+func (i item) FilterValue() string {
+	return i.cookie.Domain + " " + i.cookie.Name
+}
+
+func (i item) Title() string {
+	return secrets.Mask(fmt.Sprintf("%s: %s=%s", i.cookie.Domain, i.cookie.Name, i.cookie.Value))
+}
+
+func (i item) Description() string {
+	desc := "path " + i.cookie.Path
+	if i.cookie.Expires.IsZero() {
+		desc += ", session"
+	} else {
+		desc += ", expires " + i.cookie.Expires.Local().Format(time.DateTime)
+	}
+	if i.cookie.Secure {
+		desc += ", secure"
+	}
+	if i.cookie.HTTPOnly {
+		desc += ", http only"
+	}
+
+	return desc
+}
+
+type Model struct {
+	// env is the name of the environment whose cookies are listed.
+	env string
+
+	Jar *cookie.Jar
+	// ui
+	List    list.Model
+	Focused bool
+	Style   lipgloss.Style
+}
+
+func New(jar *cookie.Jar) *Model {
+	h, v := styles.FocusedBorder.GetFrameSize()
+	m := &Model{
+		Jar:   jar,
+		List:  list.New([]list.Item{}, list.NewDefaultDelegate(), defaultListWidth-h, defaultListHeight-v),
+		Style: styles.BorderPanel,
+	}
+
+	m.List.Styles.Title = styles.Title
+	m.List.SetShowHelp(false)
+	m.updateTitle()
+
+	return m
+}
+
+func (m *Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case environments.ChangedMsg:
+		m.env = msg.Environment.Name
+		m.updateTitle()
+		return m, m.Refresh()
+	case target.FocusMsg:
+		m.Focused = msg.FocusedTarget == target.CookiesTarget && msg.UnfocusedTarget != target.CookiesTarget
+		if m.Focused {
+			m.Style = styles.FocusedBorder
+			// responses may have set cookies since the list was last shown
+			return m, m.Refresh()
+		}
+		m.Style = styles.BorderPanel
+	case tea.WindowSizeMsg:
+		h, v := styles.FocusedBorder.GetFrameSize()
+		m.List.SetSize(msg.Width-h, msg.Height-v)
+	case tea.KeyMsg:
+		if m.List.FilterState() != list.Filtering {
+			selected, ok := m.List.SelectedItem().(item)
+			switch {
+			case ok && key.Matches(msg, editKey):
+				return m, func() tea.Msg { return EditMsg{Cookie: selected.cookie} }
+			case ok && key.Matches(msg, deleteKey):
+				return m, func() tea.Msg { return DeleteMsg{Cookie: selected.cookie} }
+			}
+		}
+
+		var cmd tea.Cmd
+		m.List, cmd = m.List.Update(msg)
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+// Set stores c in the jar, replacing the cookie with the same domain, path and name.
+func (m *Model) Set(c cookie.Cookie) tea.Cmd {
+	return m.done(m.Jar.Set(c), "Saved cookie "+c.Name)
+}
+
+// Delete removes c from the jar.
+func (m *Model) Delete(c cookie.Cookie) tea.Cmd {
+	return m.done(m.Jar.Delete(c), "Deleted cookie "+c.Name)
+}
+
+// done refreshes the list after the jar has been changed, and notifies the user of the outcome.
+func (m *Model) done(err error, msg string) tea.Cmd {
+	if err != nil {
+		return notification.Notify(notification.Error, err.Error())
+	}
+
+	return tea.Batch(m.Refresh(), notification.Notify(notification.Info, msg))
+}
+
+// Refresh updates the list to reflect the cookies in the jar.
+func (m *Model) Refresh() tea.Cmd {
+	cookies := m.Jar.List(m.env)
+
+	items := make([]list.Item, 0, len(cookies))
+	for _, c := range cookies {
+		items = append(items, item{cookie: c})
+	}
+
+	return m.List.SetItems(items)
+}
+
+func (m *Model) updateTitle() {
+	m.List.Title = "Cookies"
+	if m.env != "" {
+		m.List.Title += " · " + m.env
+	}
+}
+
+func (m *Model) View() string {
+	if len(m.List.Items()) == 0 {
+		return m.Style.Render(lipgloss.JoinVertical(
+			lipgloss.Left,
+			styles.Title.Render(m.List.Title),
+			"No cookies have been set",
+		))
+	}
+
+	return m.Style.Render(m.List.View())
+}
@@ -0,0 +1,193 @@
+package editor
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cstaaben/go-rest/internal/request"
+	"github.com/cstaaben/go-rest/internal/ui/styles"
+)
+
+// authField is an input of the auth form, bound to a field of request.Auth.
+type authField struct {
+	input textinput.Model
+	get   func(a *request.Auth) *string
+}
+
+// authForm edits the authentication of a request. The first row selects the type of authentication, and the rows
+// below it hold the credentials used by that type.
+type authForm struct {
+	authType string
+	fields   map[string][]*authField
+	// cursor is 0 for the type, or the index of the focused field plus one.
+	cursor int
+}
+
+func newAuthForm() *authForm {
+	field := func(placeholder string, password bool, get func(a *request.Auth) *string) *authField {
+		input := textinput.New()
+		input.Prompt = placeholder + ": "
+		if password {
+			input.EchoMode = textinput.EchoPassword
+		}
+
+		return &authField{input: input, get: get}
+	}
+
+	return &authForm{
+		fields: map[string][]*authField{
+			request.AuthBasic: {
+				field("Username", false, func(a *request.Auth) *string { return &a.Username }),
+				field("Password", true, func(a *request.Auth) *string { return &a.Password }),
+			},
+			request.AuthBearer: {
+				field("Token", true, func(a *request.Auth) *string { return &a.Token }),
+			},
+			request.AuthAPIKey: {
+				field("Key", false, func(a *request.Auth) *string { return &a.Key }),
+				field("Value", true, func(a *request.Auth) *string { return &a.Value }),
+				field("In (header or query)", false, func(a *request.Auth) *string { return &a.In }),
+			},
+			request.AuthDigest: {
+				field("Username", false, func(a *request.Auth) *string { return &a.Username }),
+				field("Password", true, func(a *request.Auth) *string { return &a.Password }),
+			},
+			request.AuthAWS: {
+				field("Access key", false, func(a *request.Auth) *string { return &a.AccessKey }),
+				field("Secret key", true, func(a *request.Auth) *string { return &a.SecretKey }),
+				field("Session token", true, func(a *request.Auth) *string { return &a.SessionToken }),
+				field("Region", false, func(a *request.Auth) *string { return &a.Region }),
+				field("Service", false, func(a *request.Auth) *string { return &a.Service }),
+			},
+			request.AuthOAuth2: {
+				field("Grant", false, func(a *request.Auth) *string { return &a.Grant }),
+				field("Token URL", false, func(a *request.Auth) *string { return &a.TokenURL }),
+				field("Auth URL", false, func(a *request.Auth) *string { return &a.AuthURL }),
+				field("Client ID", false, func(a *request.Auth) *string { return &a.ClientID }),
+				field("Client secret", true, func(a *request.Auth) *string { return &a.ClientSecret }),
+				field("Scope", false, func(a *request.Auth) *string { return &a.Scope }),
+				field("Username", false, func(a *request.Auth) *string { return &a.Username }),
+				field("Password", true, func(a *request.Auth) *string { return &a.Password }),
+				field("Refresh token", true, func(a *request.Auth) *string { return &a.RefreshToken }),
+				field("Redirect URL", false, func(a *request.Auth) *string { return &a.RedirectURL }),
+			},
+		},
+	}
+}
+
+// Load fills the form with auth, which is nil if the request isn't authenticated.
+func (f *authForm) Load(auth *request.Auth) {
+	f.cursor = 0
+	f.authType = request.AuthNone
+	if auth != nil {
+		f.authType = auth.Type
+	}
+
+	for _, fields := range f.fields {
+		for _, field := range fields {
+			field.input.Blur()
+			field.input.SetValue("")
+			if auth != nil {
+				field.input.SetValue(*field.get(auth))
+			}
+		}
+	}
+}
+
+// Auth returns the authentication entered in the form, or nil if there is none.
+func (f *authForm) Auth() *request.Auth {
+	if f.authType == request.AuthNone {
+		return nil
+	}
+
+	auth := &request.Auth{Type: f.authType}
+	for _, field := range f.fields[f.authType] {
+		*field.get(auth) = field.input.Value()
+	}
+
+	return auth
+}
+
+// Update handles a key press, reporting whether the authentication was changed.
+func (f *authForm) Update(msg tea.KeyMsg) (bool, tea.Cmd) {
+	fields := f.fields[f.authType]
+
+	switch msg.String() {
+	case "up":
+		return false, f.focus(max(f.cursor-1, 0))
+	case "down", tea.KeyEnter.String():
+		return false, f.focus(min(f.cursor+1, len(fields)))
+	}
+
+	if f.cursor == 0 {
+		idx := slices.Index(request.AuthTypes, f.authType)
+		switch msg.String() {
+		case "left", "h":
+			idx = (idx + len(request.AuthTypes) - 1) % len(request.AuthTypes)
+		case "right", "l", " ":
+			idx = (idx + 1) % len(request.AuthTypes)
+		default:
+			return false, nil
+		}
+
+		f.authType = request.AuthTypes[idx]
+		return true, nil
+	}
+
+	field := fields[f.cursor-1]
+	value := field.input.Value()
+
+	var cmd tea.Cmd
+	field.input, cmd = field.input.Update(msg)
+
+	return field.input.Value() != value, cmd
+}
+
+func (f *authForm) focus(cursor int) tea.Cmd {
+	f.cursor = cursor
+
+	var cmd tea.Cmd
+	for i, field := range f.fields[f.authType] {
+		if i == cursor-1 {
+			cmd = field.input.Focus()
+		} else {
+			field.input.Blur()
+		}
+	}
+
+	return cmd
+}
+
+func (f *authForm) View(focused bool) string {
+	types := make([]string, 0, len(request.AuthTypes))
+	for _, t := range request.AuthTypes {
+		name := t
+		if name == request.AuthNone {
+			name = "none"
+		}
+
+		if t == f.authType {
+			types = append(types, styles.ActiveTab.Render(name))
+		} else {
+			types = append(types, styles.Tab.Render(name))
+		}
+	}
+
+	typeRow := "Type: " + lipgloss.JoinHorizontal(lipgloss.Top, types...)
+	if focused && f.cursor == 0 {
+		typeRow = "> " + typeRow
+	} else {
+		typeRow = "  " + typeRow
+	}
+
+	lines := []string{typeRow}
+	for _, field := range f.fields[f.authType] {
+		lines = append(lines, "  "+field.input.View())
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -2,35 +2,67 @@ package editor
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
+	"slices"
+	"sort"
+	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/cstaaben/go-rest/internal/model/keymap"
 	"github.com/cstaaben/go-rest/internal/model/target"
 	"github.com/cstaaben/go-rest/internal/request"
+	"github.com/cstaaben/go-rest/internal/ui/environments"
 	"github.com/cstaaben/go-rest/internal/ui/styles"
 )
 
 const (
 	defaultWidth  = 200
 	defaultHeight = 100
+	// listWidth is the width taken up by the requests pane.
+	listWidth = 50
 
 	urlField = iota
 	tabsField
 )
 
+// mutedStyle shows hints, and values that come from the defaults of the group rather than the request itself.
+var mutedStyle = lipgloss.NewStyle().Foreground(styles.Colors().Comment)
+
+// methods are the request methods offered by the method tab.
+var methods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodHead,
+	http.MethodOptions,
+}
+
 type Model struct {
 	// ui
 	URLInput  textinput.Model
 	BodyInput textarea.Model
 	Focused   bool
 	Style     lipgloss.Style
+	Keys      *keymap.KeyMap
+	ActiveTab Tab
+	params    *table
+	headers   *table
+	auth      *authForm
+	// defaults are inherited from the group of the request, shown alongside the values of the request itself.
+	defaults *request.Defaults
 	// data
 	CurrentRequest *request.Request
 	FocusedField   int
+	// Environment is the name of the environment whose variables are used when the request is sent.
+	Environment string
 }
 
 func New() *Model {
@@ -53,12 +85,67 @@ func New() *Model {
 		return nil
 	}
 
-	return &Model{
-		URLInput:     textinput.New(),
-		BodyInput:    textarea.New(),
+	bodyInput := textarea.New()
+	bodyInput.ShowLineNumbers = true
+	bodyInput.Placeholder = "Body"
+
+	m := &Model{
+		URLInput:     urlInput,
+		BodyInput:    bodyInput,
 		FocusedField: urlField,
 		Style:        styles.BorderPanel,
+		Keys:         keymap.Default,
+		params:       newTable(false),
+		headers:      newTable(true),
+		auth:         newAuthForm(),
+	}
+	m.Load(&request.Request{Data: &request.Data{Method: http.MethodGet}}, nil)
+
+	return m
+}
+
+// Load replaces the request being edited with r, which belongs to group. Edits are made to r directly. The group may be
+// nil for requests that don't belong to one.
+func (m *Model) Load(r *request.Request, group *request.Group) {
+	if r.Data == nil {
+		r.Data = &request.Data{Method: http.MethodGet}
+	}
+
+	m.defaults = nil
+	if group != nil {
+		m.defaults = group.ResolvedDefaults()
+	}
+
+	m.CurrentRequest = r
+	m.URLInput.SetValue(r.Data.URL)
+	m.BodyInput.SetValue(r.Data.Body)
+	m.auth.Load(r.Data.Auth)
+
+	_, query, _ := request.SplitURL(r.Data.URL)
+	m.params.SetRows(ParseQuery(query))
+	m.headers.SetRows(headerRows(r.Data))
+}
+
+// headerRows lists the headers of d sorted by name, followed by its disabled headers.
+func headerRows(d *request.Data) []Row {
+	return append(rowsOf(d.Headers, true), rowsOf(d.DisabledHeaders, false)...)
+}
+
+func rowsOf(headers map[string][]string, enabled bool) []Row {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+
+	var rows []Row
+	for _, name := range names {
+		for _, value := range headers[name] {
+			rows = append(rows, Row{Key: name, Value: value, Enabled: enabled})
+		}
+	}
+
+	return rows
 }
 
 // Init is the first function that will be called. It returns an optional
@@ -81,12 +168,14 @@ func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		// TODO: update the width of the editor pane to be a % of the total window size
-		// h := m.Style.GetHorizontalFrameSize()
-		// m.URLInput.Width = msg.Width - h
-		// m.Style.Width(msg.Width - h)
-		m.Style = m.Style.Height(m.Style.GetHeight()).
-			Width(m.Style.GetWidth())
+		h, v := m.Style.GetFrameSize()
+		width := msg.Width - listWidth - h
+		m.URLInput.Width = width - len(http.MethodOptions) - 1
+		m.BodyInput.SetWidth(width)
+		// the editor shares the height with the response, and needs room for the title, URL and tab bar
+		m.BodyInput.SetHeight(max(msg.Height/2-v-3, 1))
+	case environments.ChangedMsg:
+		m.Environment = msg.Environment.Name
 	case target.FocusMsg:
 		m.Focused = msg.FocusedTarget == target.EditorTarget && msg.UnfocusedTarget != target.EditorTarget
 
@@ -94,31 +183,246 @@ func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 		s := lipgloss.NewStyle().Width(m.Style.GetWidth()).Height(m.Style.GetHeight())
 		if m.Focused {
 			m.Style = s.Inherit(styles.FocusedBorder)
+			commands = append(commands, m.focusField(m.FocusedField))
 		} else {
 			m.Style = s.Inherit(styles.BorderPanel)
+			m.URLInput.Blur()
+			m.BodyInput.Blur()
 		}
-
-		commands = append(commands, m.URLInput.Focus())
+	case tea.KeyMsg:
+		commands = append(commands, m.handleKey(msg))
 	default:
-		var (
-			urlInputCmd  tea.Cmd
-			bodyInputCmd tea.Cmd
-		)
+		// cursor blinks
+		var urlInputCmd, bodyInputCmd tea.Cmd
 		m.URLInput, urlInputCmd = m.URLInput.Update(msg)
-		// m.BodyInput, bodyInputCmd = m.BodyInput.Update(msg)
+		m.BodyInput, bodyInputCmd = m.BodyInput.Update(msg)
 		commands = append(commands, urlInputCmd, bodyInputCmd)
 	}
 
 	return m, tea.Batch(commands...)
 }
 
+func (m *Model) handleKey(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.Keys.NextTab):
+		m.ActiveTab = m.ActiveTab.Next()
+		return m.focusField(m.FocusedField)
+	case key.Matches(msg, m.Keys.PreviousTab):
+		m.ActiveTab = m.ActiveTab.Prev()
+		return m.focusField(m.FocusedField)
+	}
+
+	data := m.CurrentRequest.Data
+
+	if m.FocusedField == urlField {
+		if msg.Type == tea.KeyEnter || msg.Type == tea.KeyDown {
+			return m.focusField(tabsField)
+		}
+
+		var cmd tea.Cmd
+		m.URLInput, cmd = m.URLInput.Update(msg)
+		if value := m.URLInput.Value(); value != data.URL {
+			data.URL = value
+			_, query, _ := request.SplitURL(value)
+			m.params.SetRows(ParseQuery(query))
+		}
+
+		return cmd
+	}
+
+	// escape returns to the URL, unless it cancels editing a cell
+	if msg.Type == tea.KeyEsc && !m.params.editing && !m.headers.editing {
+		return m.focusField(urlField)
+	}
+
+	var cmd tea.Cmd
+	switch m.ActiveTab {
+	case MethodTab:
+		idx := slices.Index(methods, strings.ToUpper(data.Method))
+		switch msg.String() {
+		case "up", "k":
+			data.Method = methods[max(idx-1, 0)]
+		case "down", "j":
+			data.Method = methods[min(idx+1, len(methods)-1)]
+		case "c":
+			data.NoCookies = !data.NoCookies
+		case "p":
+			data.Proto = request.Protos[(slices.Index(request.Protos, data.Proto)+1)%len(request.Protos)]
+		case "r":
+			redirects := m.redirects().Clone()
+			if redirects == nil {
+				redirects = new(request.Redirects)
+			}
+			follow := !redirects.Following()
+			redirects.Follow = &follow
+			data.Redirects = redirects
+		}
+	case ParamsTab:
+		var changed bool
+		if changed, cmd = m.params.Update(msg); changed {
+			data.URL = BuildURL(data.URL, m.params.rows)
+			m.URLInput.SetValue(data.URL)
+		}
+	case HeadersTab:
+		var changed bool
+		if changed, cmd = m.headers.Update(msg); changed {
+			data.Headers, data.DisabledHeaders = splitHeaders(m.headers.rows)
+		}
+	case BodyTab:
+		m.BodyInput, cmd = m.BodyInput.Update(msg)
+		data.Body = m.BodyInput.Value()
+	case AuthTab:
+		var changed bool
+		if changed, cmd = m.auth.Update(msg); changed {
+			data.Auth = m.auth.Auth()
+		}
+	}
+
+	return cmd
+}
+
+// splitHeaders converts the rows of the headers table to the enabled and disabled headers of a request.
+func splitHeaders(rows []Row) (map[string][]string, map[string][]string) {
+	var enabled, disabled map[string][]string
+	for _, r := range rows {
+		if r.Key == "" {
+			continue
+		}
+
+		headers := &enabled
+		if !r.Enabled {
+			headers = &disabled
+		}
+		if *headers == nil {
+			*headers = make(map[string][]string)
+		}
+
+		(*headers)[r.Key] = append((*headers)[r.Key], r.Value)
+	}
+
+	return enabled, disabled
+}
+
+// focusField moves the focus to the URL or the content of the active tab.
+func (m *Model) focusField(field int) tea.Cmd {
+	m.FocusedField = field
+	m.URLInput.Blur()
+	m.BodyInput.Blur()
+
+	switch {
+	case field == urlField:
+		return m.URLInput.Focus()
+	case m.ActiveTab == BodyTab:
+		return m.BodyInput.Focus()
+	case m.ActiveTab == AuthTab:
+		return m.auth.focus(m.auth.cursor)
+	}
+
+	return nil
+}
+
 // View renders the program's UI, which is just a string. The view is
 // rendered after every Update.
 func (m *Model) View() string {
-	addrInput := m.URLInput.View()
-	return m.Style.Render(addrInput)
-	// bodyInput := m.BodyInput.View()
-	// joined := lipgloss.JoinVertical(lipgloss.Top, addrInput, bodyInput)
+	name := m.CurrentRequest.Name
+	if name == "" {
+		name = "New request"
+	}
+
+	env := "No environment"
+	if m.Environment != "" {
+		env = "Environment: " + m.Environment
+	}
+
+	method := m.CurrentRequest.Data.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	methodStyle := lipgloss.NewStyle().Bold(true).Foreground(styles.Colors().Key)
+	addrInput := methodStyle.Render(strings.ToUpper(method)) + " " + m.URLInput.View()
+
+	tabsFocused := m.Focused && m.FocusedField == tabsField
+
+	var content string
+	switch m.ActiveTab {
+	case MethodTab:
+		lines := make([]string, 0, len(methods))
+		for _, method := range methods {
+			if strings.EqualFold(method, m.CurrentRequest.Data.Method) {
+				lines = append(lines, methodStyle.Render("> "+method))
+			} else {
+				lines = append(lines, "  "+method)
+			}
+		}
+
+		cookies := "Cookies: sent (c to toggle)"
+		if m.CurrentRequest.Data.NoCookies {
+			cookies = "Cookies: not sent (c to toggle)"
+		}
+		redirects := fmt.Sprintf("Redirects: up to %d followed (r to toggle)", m.redirects().Limit())
+		if !m.redirects().Following() {
+			redirects = "Redirects: not followed (r to toggle)"
+		}
+		proto := m.CurrentRequest.Data.Proto
+		if proto == request.ProtoDefault {
+			proto = "negotiated"
+		}
+		lines = append(
+			lines,
+			"",
+			mutedStyle.Render("Protocol: "+proto+" (p to change)"),
+			mutedStyle.Render(cookies),
+			mutedStyle.Render(redirects),
+		)
+		content = strings.Join(lines, "\n")
+	case ParamsTab:
+		content = m.params.View(tabsFocused)
+	case HeadersTab:
+		content = m.headers.View(tabsFocused)
+		if inherited := m.inheritedHeaders(); inherited != "" {
+			content = lipgloss.JoinVertical(lipgloss.Left, content, inherited)
+		}
+	case BodyTab:
+		content = m.BodyInput.View()
+	case AuthTab:
+		content = m.auth.View(tabsFocused)
+	}
+
+	lines := []string{styles.Title.Render(name + " · " + env), addrInput}
+	if m.defaults != nil && m.defaults.BaseURL != "" {
+		lines = append(lines, mutedStyle.Render("base URL: "+m.defaults.BaseURL))
+	}
+	lines = append(lines, renderTabs(m.ActiveTab), content)
+
+	return m.Style.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// redirects returns how redirects are handled for the request, which is inherited from the group unless the request
+// sets it itself.
+func (m *Model) redirects() *request.Redirects {
+	if redirects := m.CurrentRequest.Data.Redirects; redirects != nil || m.defaults == nil {
+		return redirects
+	}
+
+	return m.defaults.Redirects
+}
+
+// inheritedHeaders renders the default headers of the group that the request doesn't set or disable itself.
+func (m *Model) inheritedHeaders() string {
+	if m.defaults == nil {
+		return ""
+	}
+
+	rows := rowsOf(m.defaults.InheritedHeaders(m.CurrentRequest.Data), true)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, len(rows))
+	for _, r := range rows {
+		lines = append(lines, mutedStyle.Render(fmt.Sprintf("    %s: %s (inherited)", r.Key, r.Value)))
+	}
 
-	// return styles.BorderPanel.Width(defaultWidth).Height(defaultHeight).Render(joined)
+	return strings.Join(lines, "\n")
 }
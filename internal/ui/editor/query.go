@@ -0,0 +1,41 @@
+package editor
+
+import (
+	"strings"
+
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+// ParseQuery lists the parameters of query in the order they appear.
+func ParseQuery(query string) []Row {
+	var rows []Row
+	for _, param := range strings.Split(query, "&") {
+		if param == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(param, "=")
+		rows = append(rows, Row{Key: request.UnescapeQuery(key), Value: request.UnescapeQuery(value), Enabled: true})
+	}
+
+	return rows
+}
+
+// BuildURL replaces the query of rawURL with the parameters in rows.
+func BuildURL(rawURL string, rows []Row) string {
+	base, _, fragment := request.SplitURL(rawURL)
+
+	params := make([]string, 0, len(rows))
+	for _, r := range rows {
+		if r.Key == "" {
+			continue
+		}
+		params = append(params, request.EscapeQuery(r.Key)+"="+request.EscapeQuery(r.Value))
+	}
+
+	if len(params) == 0 {
+		return base + fragment
+	}
+
+	return base + "?" + strings.Join(params, "&") + fragment
+}
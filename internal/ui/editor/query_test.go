@@ -0,0 +1,88 @@
+package editor_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cstaaben/go-rest/internal/request"
+	"github.com/cstaaben/go-rest/internal/ui/editor"
+)
+
+func TestParseQuery(t *testing.T) {
+	testCases := []struct {
+		name     string
+		url      string
+		expected []editor.Row
+	}{
+		{
+			name: "No query",
+			url:  "https://example.com/path",
+		},
+		{
+			name: "Parameters in order",
+			url:  "https://example.com/?b=2&a=hello+world&flag#top",
+			expected: []editor.Row{
+				{Key: "b", Value: "2", Enabled: true},
+				{Key: "a", Value: "hello world", Enabled: true},
+				{Key: "flag", Value: "", Enabled: true},
+			},
+		},
+		{
+			name: "Placeholders",
+			url:  "https://{{host}}/?id={{ $randomInt 1 100 }}&bad=%zz",
+			expected: []editor.Row{
+				{Key: "id", Value: "{{ $randomInt 1 100 }}", Enabled: true},
+				{Key: "bad", Value: "%zz", Enabled: true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				_, query, _ := request.SplitURL(tc.url)
+				assert.Equal(t, tc.expected, editor.ParseQuery(query))
+			},
+		)
+	}
+}
+
+func TestBuildURL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		url      string
+		rows     []editor.Row
+		expected string
+	}{
+		{
+			name:     "Add query",
+			url:      "https://example.com/path#top",
+			rows:     []editor.Row{{Key: "q", Value: "a b&c"}, {Key: "", Value: "ignored"}},
+			expected: "https://example.com/path?q=a+b%26c#top",
+		},
+		{
+			name:     "Replace query",
+			url:      "https://{{host}}/?old=1",
+			rows:     []editor.Row{{Key: "id", Value: "{{ $uuid }}"}, {Key: "page", Value: "2"}},
+			expected: "https://{{host}}/?id={{ $uuid }}&page=2",
+		},
+		{
+			name:     "Remove query",
+			url:      "https://example.com/?old=1",
+			expected: "https://example.com/",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				assert.Equal(t, tc.expected, editor.BuildURL(tc.url, tc.rows))
+			},
+		)
+	}
+}
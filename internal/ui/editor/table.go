@@ -0,0 +1,179 @@
+package editor
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cstaaben/go-rest/internal/ui/styles"
+)
+
+// Row is a key/value pair in a table, such as a query parameter or a header.
+type Row struct {
+	Key     string
+	Value   string
+	Enabled bool
+}
+
+var (
+	addRowKey = key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "Add row"),
+	)
+	deleteRowKey = key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "Delete row"),
+	)
+	toggleRowKey = key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "Enable/disable row"),
+	)
+	editCellKey = key.NewBinding(
+		key.WithKeys(tea.KeyEnter.String()),
+		key.WithHelp(tea.KeyEnter.String(), "Edit cell"),
+	)
+)
+
+// table edits a list of key/value rows. A cell is edited by selecting it and pressing enter.
+type table struct {
+	rows []Row
+	// toggles allows rows to be disabled without removing them.
+	toggles bool
+	cursor  int
+	// column is 0 for keys and 1 for values.
+	column  int
+	editing bool
+	input   textinput.Model
+}
+
+func newTable(toggles bool) *table {
+	input := textinput.New()
+	input.Prompt = ""
+
+	return &table{toggles: toggles, input: input}
+}
+
+// SetRows replaces the rows of the table.
+func (t *table) SetRows(rows []Row) {
+	t.rows = rows
+	t.editing = false
+	t.input.Blur()
+	t.cursor = min(t.cursor, max(len(rows)-1, 0))
+}
+
+// Update handles a key press, reporting whether the rows were changed.
+func (t *table) Update(msg tea.KeyMsg) (bool, tea.Cmd) {
+	if t.editing {
+		switch msg.Type {
+		case tea.KeyEnter:
+			t.editing = false
+			t.input.Blur()
+			if t.column == 0 {
+				t.rows[t.cursor].Key = t.input.Value()
+			} else {
+				t.rows[t.cursor].Value = t.input.Value()
+			}
+			return true, nil
+		case tea.KeyEsc:
+			t.editing = false
+			t.input.Blur()
+			return false, nil
+		}
+
+		var cmd tea.Cmd
+		t.input, cmd = t.input.Update(msg)
+		return false, cmd
+	}
+
+	switch {
+	case key.Matches(msg, addRowKey):
+		t.rows = append(t.rows, Row{Enabled: true})
+		t.cursor, t.column = len(t.rows)-1, 0
+		return true, t.edit()
+	case len(t.rows) == 0:
+		return false, nil
+	case key.Matches(msg, deleteRowKey):
+		t.rows = append(t.rows[:t.cursor], t.rows[t.cursor+1:]...)
+		t.cursor = min(t.cursor, max(len(t.rows)-1, 0))
+		return true, nil
+	case key.Matches(msg, toggleRowKey) && t.toggles:
+		t.rows[t.cursor].Enabled = !t.rows[t.cursor].Enabled
+		return true, nil
+	case key.Matches(msg, editCellKey):
+		return false, t.edit()
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		t.cursor = max(t.cursor-1, 0)
+	case "down", "j":
+		t.cursor = min(t.cursor+1, len(t.rows)-1)
+	case "left", "h":
+		t.column = 0
+	case "right", "l":
+		t.column = 1
+	}
+
+	return false, nil
+}
+
+// edit starts editing the selected cell.
+func (t *table) edit() tea.Cmd {
+	t.editing = true
+	if t.column == 0 {
+		t.input.SetValue(t.rows[t.cursor].Key)
+	} else {
+		t.input.SetValue(t.rows[t.cursor].Value)
+	}
+	t.input.CursorEnd()
+
+	return t.input.Focus()
+}
+
+func (t *table) View(focused bool) string {
+	if len(t.rows) == 0 {
+		return styles.Title.Render("No rows, press a to add one")
+	}
+
+	keyWidth := 0
+	for _, r := range t.rows {
+		keyWidth = max(keyWidth, lipgloss.Width(r.Key))
+	}
+
+	cell := lipgloss.NewStyle()
+	selected := lipgloss.NewStyle().Reverse(true)
+	disabled := lipgloss.NewStyle().Foreground(styles.Colors().Comment).Strikethrough(true)
+
+	lines := make([]string, 0, len(t.rows))
+	for i, r := range t.rows {
+		cells := []string{r.Key, r.Value}
+		for c := range cells {
+			style := cell
+			switch {
+			case focused && i == t.cursor && c == t.column && t.editing:
+				cells[c] = t.input.View()
+				continue
+			case focused && i == t.cursor && c == t.column:
+				style = selected
+			case !r.Enabled:
+				style = disabled
+			}
+			cells[c] = style.Render(cells[c])
+		}
+
+		line := lipgloss.NewStyle().Width(keyWidth).Render(cells[0]) + " : " + cells[1]
+		if t.toggles {
+			check := "[x] "
+			if !r.Enabled {
+				check = "[ ] "
+			}
+			line = check + line
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,61 @@
+package editor
+
+import (
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cstaaben/go-rest/internal/ui/styles"
+)
+
+// Tab is one of the parts of a request that can be edited.
+type Tab int
+
+const (
+	MethodTab Tab = iota
+	ParamsTab
+	HeadersTab
+	BodyTab
+	AuthTab
+
+	tabCount = int(AuthTab) + 1
+)
+
+func (t Tab) String() string {
+	switch t {
+	case MethodTab:
+		return "Method"
+	case ParamsTab:
+		return "Params"
+	case HeadersTab:
+		return "Headers"
+	case BodyTab:
+		return "Body"
+	case AuthTab:
+		return "Auth"
+	default:
+		return ""
+	}
+}
+
+// Next returns the tab after t, wrapping around to the first tab.
+func (t Tab) Next() Tab {
+	return Tab((int(t) + 1) % tabCount)
+}
+
+// Prev returns the tab before t, wrapping around to the last tab.
+func (t Tab) Prev() Tab {
+	return Tab((int(t) + tabCount - 1) % tabCount)
+}
+
+// renderTabs renders the tab bar with active highlighted.
+func renderTabs(active Tab) string {
+	tabs := make([]string, 0, tabCount)
+	for t := Tab(0); int(t) < tabCount; t++ {
+		if t == active {
+			tabs = append(tabs, styles.ActiveTab.Render(t.String()))
+		} else {
+			tabs = append(tabs, styles.Tab.Render(t.String()))
+		}
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+}
@@ -1,29 +1,174 @@
 package enveditor
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"log/slog"
+
+	"github.com/cstaaben/go-rest/internal/environment"
+	"github.com/cstaaben/go-rest/internal/model/keymap"
+	"github.com/cstaaben/go-rest/internal/model/target"
+	"github.com/cstaaben/go-rest/internal/secrets"
+	"github.com/cstaaben/go-rest/internal/ui/environments"
+	"github.com/cstaaben/go-rest/internal/ui/notification"
+	"github.com/cstaaben/go-rest/internal/ui/styles"
+)
+
+const (
+	defaultWidth  = 80
+	defaultHeight = 40
+	// listWidth is the width taken up by the environments pane.
+	listWidth = 50
 )
 
+// SavedMsg is sent when an environment has been written to disk.
+type SavedMsg struct {
+	Environment *environment.Environment
+}
+
 type Model struct {
 	// ui
 	TextArea *textarea.Model
 	Focused  bool
 	Style    lipgloss.Style
+	Keys     *keymap.KeyMap
+	// data
+	Environment *environment.Environment
+	// environments are all the loaded environments, which the environment being edited can extend.
+	environments []*environment.Environment
+	// saved is the content of the environment file as it was last loaded or saved.
+	saved string
+	// Err is the error from parsing the current content, if it is invalid.
+	Err error
+}
+
+func New() *Model {
+	ta := textarea.New()
+	ta.ShowLineNumbers = true
+	ta.SetWidth(defaultWidth)
+	ta.SetHeight(defaultHeight)
+
+	return &Model{
+		TextArea: &ta,
+		Style:    styles.BorderPanel,
+		Keys:     keymap.Default,
+	}
 }
 
 func (m *Model) Init() tea.Cmd {
-	// TODO implement me
-	panic("implement me")
+	return nil
+}
+
+// Dirty reports whether the content has been changed since it was last loaded or saved.
+func (m *Model) Dirty() bool {
+	return m.Environment != nil && m.TextArea.Value() != m.saved
 }
 
 func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
-	// TODO implement me
-	return m, nil
+	var commands []tea.Cmd
+
+	switch msg := msg.(type) {
+	case environments.LoadedMsg:
+		m.environments = msg.Environments
+	case environments.ChangedMsg:
+		if err := m.load(msg.Environment); err != nil {
+			commands = append(commands, notification.Notify(notification.Error, err.Error()))
+		}
+	case target.FocusMsg:
+		m.Focused = msg.FocusedTarget == target.EnvEditorTarget && msg.UnfocusedTarget != target.EnvEditorTarget
+		if m.Focused {
+			m.Style = styles.FocusedBorder
+			commands = append(commands, m.TextArea.Focus())
+		} else {
+			m.Style = styles.BorderPanel
+			m.TextArea.Blur()
+		}
+	case tea.WindowSizeMsg:
+		h, v := m.Style.GetFrameSize()
+		m.TextArea.SetWidth(msg.Width - listWidth - h)
+		// leave room for the title and error lines
+		m.TextArea.SetHeight(msg.Height - v - 3)
+	case tea.KeyMsg:
+		if key.Matches(msg, m.Keys.Save) {
+			return m, m.save()
+		}
+
+		if m.Environment == nil {
+			return m, nil
+		}
+
+		ta, cmd := m.TextArea.Update(msg)
+		m.TextArea = &ta
+		commands = append(commands, cmd)
+
+		// validate on every change with the same rules used when loading environments
+		_, m.Err = environment.Parse([]byte(m.TextArea.Value()))
+	}
+
+	return m, tea.Batch(commands...)
+}
+
+// load replaces the content of the editor with the file env was loaded from.
+func (m *Model) load(env *environment.Environment) error {
+	m.Environment = env
+	m.Err = nil
+
+	body, err := os.ReadFile(env.Path)
+	if err != nil {
+		m.saved = ""
+		m.TextArea.SetValue("")
+		return fmt.Errorf("reading environment %s: %w", env.Name, err)
+	}
+
+	m.saved = string(body)
+	m.TextArea.SetValue(m.saved)
+
+	return nil
+}
+
+func (m *Model) save() tea.Cmd {
+	if m.Environment == nil {
+		return nil
+	}
+
+	content := m.TextArea.Value()
+	if err := m.Environment.Save([]byte(content), m.environments); err != nil {
+		m.Err = err
+		return notification.Notify(notification.Error, "saving environment: "+err.Error())
+	}
+
+	m.saved = content
+	m.Err = nil
+	slog.Debug("environment saved", slog.String("name", m.Environment.Name), slog.String("path", m.Environment.Path))
+
+	env := m.Environment
+	return tea.Batch(
+		notification.Notify(notification.Info, "Saved environment "+env.Name),
+		func() tea.Msg { return SavedMsg{Environment: env} },
+	)
 }
 
 func (m *Model) View() string {
-	// TODO implement me
-	panic("implement me")
+	title := "No environment selected"
+	if m.Environment != nil {
+		title = m.Environment.Name
+		if m.Dirty() {
+			title += " (modified)"
+		}
+	}
+
+	var status string
+	if m.Err != nil {
+		status = styles.ErrorText.Render(m.Err.Error())
+	}
+
+	// secret values pasted into an environment are never shown
+	editor := secrets.Mask(m.TextArea.View())
+
+	return m.Style.Render(lipgloss.JoinVertical(lipgloss.Left, styles.Title.Render(title), editor, status))
 }
@@ -1,33 +1,132 @@
 package environments
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"os"
+	"path"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"log/slog"
 
 	"github.com/cstaaben/go-rest/internal/environment"
+	"github.com/cstaaben/go-rest/internal/model/target"
+	"github.com/cstaaben/go-rest/internal/oauth"
+	"github.com/cstaaben/go-rest/internal/secrets"
+	"github.com/cstaaben/go-rest/internal/ui/notification"
+	"github.com/cstaaben/go-rest/internal/ui/styles"
 )
 
+const (
+	defaultListWidth  = 50
+	defaultListHeight = 100
+
+	// Dir is the name of the directory in the data directory that environments are loaded from.
+	Dir = "environments"
+)
+
+var (
+	selectKey = key.NewBinding(
+		key.WithKeys(tea.KeyEnter.String()),
+		key.WithHelp(tea.KeyEnter.String(), "Select environment"),
+	)
+	clearKey = key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "Clear captured variables and tokens"),
+	)
+)
+
+// LoadedMsg is sent when the environments have been loaded from disk.
+type LoadedMsg struct {
+	Environments []*environment.Environment
+}
+
+// SelectMsg is sent when the user picks an environment from the list. The selection only takes effect once Select is
+// called, so it can be confirmed first.
+type SelectMsg struct {
+	Environment *environment.Environment
+}
+
+// ChangedMsg is sent whenever a different environment is selected.
+type ChangedMsg struct {
+	Environment *environment.Environment
+}
+
+// Changed returns a command announcing that env has been selected.
+func Changed(env *environment.Environment) tea.Cmd {
+	return func() tea.Msg {
+		return ChangedMsg{Environment: env}
+	}
+}
+
+// item displays an environment in the list, marking the selected one.
+type item struct {
+	env      *environment.Environment
+	selected bool
+}
+
+func (i item) FilterValue() string {
+	return i.env.Name
+}
+
+func (i item) Title() string {
+	if i.selected {
+		return "● " + i.env.Name
+	}
+
+	return i.env.Name
+}
+
+func (i item) Description() string {
+	return fmt.Sprintf("%d variables", len(i.env.Variables))
+}
+
 type Model struct {
 	defaultEnv string
 	dataDir    string
 
 	Environments []*environment.Environment
 	Selected     *environment.Environment
+	// Tokens are the OAuth 2.0 access tokens cached for each environment, if any.
+	Tokens *oauth.Tokens
+	// ui
+	List    list.Model
+	Focused bool
+	Style   lipgloss.Style
 }
 
-func New(dataDir string) *Model {
+func New(dataDir, defaultEnv string) *Model {
+	h, v := styles.FocusedBorder.GetFrameSize()
 	m := &Model{
+		defaultEnv:   defaultEnv,
 		dataDir:      dataDir,
 		Environments: make([]*environment.Environment, 0),
+		List:         list.New([]list.Item{}, list.NewDefaultDelegate(), defaultListWidth-h, defaultListHeight-v),
+		Style:        styles.BorderPanel,
 	}
 
+	m.List.Title = "Environments"
+	m.List.Styles.Title = styles.Title
+	m.List.SetShowHelp(false)
+
 	return m
 }
 
 func loadEnvironments(dataDir string) ([]*environment.Environment, error) {
-	panic("not implemented") // TODO: Implement
+	envs, err := environment.Load(path.Join(dataDir, Dir))
+	if errors.Is(err, os.ErrNotExist) {
+		// having no environments is not an error, requests just can't use variables
+		return make([]*environment.Environment, 0), nil
+	}
+
+	return envs, err
 }
 
 // Init is the first function that will be called. It returns an optional
@@ -39,30 +138,173 @@ func (model *Model) Init() tea.Cmd {
 			return fmt.Errorf("loading environments: %w", err)
 		}
 
-		model.Environments = envs
+		return LoadedMsg{Environments: envs}
+	}
+}
+
+// Update is called when a message is received. Use it to inspect messages
+// and, in response, update the model and/or send a command.
+func (model *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	var commands []tea.Cmd
+
+	switch msg := msg.(type) {
+	case LoadedMsg:
+		model.Environments = msg.Environments
+		slog.Debug("environments loaded", slog.Int("count", len(model.Environments)))
 
 		if model.defaultEnv != "" {
 			for _, env := range model.Environments {
 				if strings.EqualFold(env.Name, model.defaultEnv) {
 					model.Selected = env
+					commands = append(commands, Changed(env))
 					break
 				}
 			}
 		}
 
-		return nil
+		commands = append(commands, model.Refresh())
+	case target.FocusMsg:
+		model.Focused = msg.FocusedTarget == target.EnvironmentsTarget && msg.UnfocusedTarget != target.EnvironmentsTarget
+		if model.Focused {
+			model.Style = styles.FocusedBorder
+		} else {
+			model.Style = styles.BorderPanel
+		}
+	case tea.WindowSizeMsg:
+		// the list shares the pane with the variables of the highlighted environment
+		h, v := styles.FocusedBorder.GetFrameSize()
+		model.List.SetSize(defaultListWidth-h, (msg.Height-v)/2)
+	case tea.KeyMsg:
+		if key.Matches(msg, selectKey) && model.List.FilterState() != list.Filtering {
+			if selected, ok := model.List.SelectedItem().(item); ok {
+				return model, func() tea.Msg { return SelectMsg{Environment: selected.env} }
+			}
+		}
+
+		if key.Matches(msg, clearKey) && model.List.FilterState() != list.Filtering {
+			selected, ok := model.List.SelectedItem().(item)
+			if ok && (len(selected.env.Captured) > 0 || len(model.tokens(selected.env)) > 0) {
+				selected.env.ClearCaptured()
+				if model.Tokens != nil {
+					model.Tokens.Clear(selected.env.Name)
+				}
+				return model, notification.Notify(notification.Info, "Cleared captured variables and tokens of "+selected.env.Name)
+			}
+		}
+
+		var cmd tea.Cmd
+		model.List, cmd = model.List.Update(msg)
+		commands = append(commands, cmd)
 	}
+
+	return model, tea.Batch(commands...)
 }
 
-// Update is called when a message is received. Use it to inspect messages
-// and, in response, update the model and/or send a command.
-func (model *Model) Update(_ tea.Msg) (*Model, tea.Cmd) {
-	// TODO: Implement
-	return model, nil
+// Select makes env the selected environment and announces the change.
+func (model *Model) Select(env *environment.Environment) tea.Cmd {
+	model.Selected = env
+	slog.Debug("environment selected", slog.String("name", env.Name))
+
+	return tea.Batch(Changed(env), model.Refresh())
+}
+
+// Relink updates the inheritance between environments after one of them has changed, then refreshes the list.
+func (model *Model) Relink() tea.Cmd {
+	if err := environment.Link(model.Environments); err != nil {
+		return notification.Notify(notification.Error, err.Error())
+	}
+
+	return model.Refresh()
+}
+
+// Refresh updates the list to reflect the loaded environments and which of them is selected.
+func (model *Model) Refresh() tea.Cmd {
+	items := make([]list.Item, 0, len(model.Environments))
+	for _, env := range model.Environments {
+		items = append(items, item{env: env, selected: env == model.Selected})
+	}
+
+	return model.List.SetItems(items)
 }
 
 // View renders the program's UI, which is just a string. The view is
 // rendered after every Update.
 func (model *Model) View() string {
-	panic("not implemented") // TODO: Implement
+	return model.Style.Render(lipgloss.JoinVertical(lipgloss.Left, model.List.View(), model.variables()))
+}
+
+// variables renders the resolved variables of the highlighted environment, showing which values are inherited and
+// which override an inherited value.
+func (model *Model) variables() string {
+	selected, ok := model.List.SelectedItem().(item)
+	if !ok {
+		return ""
+	}
+
+	inherited := lipgloss.NewStyle().Foreground(styles.Colors().Comment)
+	overridden := lipgloss.NewStyle().Foreground(styles.Colors().Key)
+
+	lines := []string{styles.Title.Render("Variables")}
+	if parent := selected.env.Parent(); parent != nil {
+		lines = append(lines, inherited.Render("extends "+parent.Name))
+	}
+
+	for _, v := range selected.env.Values() {
+		line := secrets.Mask(fmt.Sprintf("%s: %v", v.Path, v.Value))
+		switch {
+		case v.Source != selected.env.Name:
+			line = inherited.Render(line + " (from " + v.Source + ")")
+		case v.Overrides:
+			line = overridden.Render(line + " (overridden)")
+		}
+
+		lines = append(lines, line)
+	}
+
+	if len(selected.env.Captured) > 0 {
+		lines = append(lines, "", styles.Title.Render("Captured"), inherited.Render("x to clear"))
+
+		names := slices.Sorted(maps.Keys(selected.env.Captured))
+		for _, name := range names {
+			lines = append(lines, secrets.Mask(fmt.Sprintf("%s: %v", name, selected.env.Captured[name])))
+		}
+	}
+
+	if tokens := model.tokens(selected.env); len(tokens) > 0 {
+		lines = append(lines, "", styles.Title.Render("Tokens"), inherited.Render("x to clear"))
+
+		for _, entry := range tokens {
+			expiry := "no expiry"
+			if !entry.Token.Expiry.IsZero() {
+				expiry = "expires " + entry.Token.Expiry.Format(time.Kitchen)
+			}
+
+			token := truncate(entry.Token.AccessToken, 8)
+			line := fmt.Sprintf("%s (%s): %s…, %s", entry.ClientID, entry.TokenURL, token, expiry)
+			if entry.Scope != "" {
+				line += ", scope " + entry.Scope
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// tokens returns the OAuth 2.0 access tokens cached for env.
+func (model *Model) tokens(env *environment.Environment) []oauth.Entry {
+	if model.Tokens == nil {
+		return nil
+	}
+
+	return model.Tokens.List(env.Name)
+}
+
+// truncate shortens s to at most n characters, so only the start of a token is shown.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return s[:n]
 }
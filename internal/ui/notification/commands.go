@@ -0,0 +1,15 @@
+package notification
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Notify returns a command that displays message at the given level.
+func Notify(level Level, message string) tea.Cmd {
+	return func() tea.Msg {
+		return Notification{
+			Message: message,
+			Level:   level,
+		}
+	}
+}
@@ -14,6 +14,21 @@ type Notification struct {
 	Level   Level
 }
 
+func (l Level) String() string {
+	switch l {
+	case Debug:
+		return "debug"
+	case Info:
+		return "info"
+	case Warn:
+		return "warning"
+	case Error:
+		return "error"
+	default:
+		return ""
+	}
+}
+
 func (n *Notification) Error() string {
 	return n.Message
 }
@@ -0,0 +1,27 @@
+package requests
+
+import (
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+// LoadedMsg is sent when the requests have been loaded from disk.
+type LoadedMsg struct {
+	Groups []*request.Group
+	// Warnings are the errors of the files that were skipped because they couldn't be loaded.
+	Warnings []error
+}
+
+// SelectedMsg is sent when a request is picked from the list, so it can be loaded into the editor.
+type SelectedMsg struct {
+	Request *request.Request
+}
+
+// RenameMsg is sent when the user asks to rename a request, so a new name can be prompted for.
+type RenameMsg struct {
+	Request *request.Request
+}
+
+// DeleteMsg is sent when the user asks to delete a request, so it can be confirmed first.
+type DeleteMsg struct {
+	Request *request.Request
+}
@@ -20,8 +20,12 @@
 package requests
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -32,12 +36,39 @@ import (
 	"github.com/cstaaben/go-rest/internal/model/target"
 	"github.com/cstaaben/go-rest/internal/request"
 	"github.com/cstaaben/go-rest/internal/ui/help"
+	"github.com/cstaaben/go-rest/internal/ui/notification"
 	"github.com/cstaaben/go-rest/internal/ui/styles"
 )
 
 const (
 	defaultListWidth  = 50
 	defaultListHeight = 100
+
+	// Dir is the name of the directory in the data directory that requests are loaded from.
+	Dir = "requests"
+)
+
+var (
+	selectKey = key.NewBinding(
+		key.WithKeys(tea.KeyEnter.String(), "l"),
+		key.WithHelp(tea.KeyEnter.String(), "Open request or expand group"),
+	)
+	backKey = key.NewBinding(
+		key.WithKeys(tea.KeyBackspace.String(), "h"),
+		key.WithHelp(tea.KeyBackspace.String(), "Collapse group"),
+	)
+	renameKey = key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "Rename request"),
+	)
+	duplicateKey = key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "Duplicate request"),
+	)
+	deleteKey = key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "Delete request"),
+	)
 )
 
 // var keys = viewport.KeyMap{
@@ -69,6 +100,8 @@ type Model struct {
 	dataDir  string
 	Selected *request.Request
 	Requests []*request.Group
+	// expanded is the set of groups whose content is shown in the tree.
+	expanded map[*request.Group]bool
 	// ui
 	List    list.Model
 	Focused bool
@@ -80,9 +113,10 @@ type Model struct {
 func New(dataDir string) *Model {
 	h, v := styles.FocusedBorder.GetFrameSize()
 	m := &Model{
-		dataDir: dataDir,
-		List:    list.New([]list.Item{}, list.NewDefaultDelegate(), defaultListWidth-h, defaultListHeight-v),
-		Style:   styles.BorderPanel,
+		dataDir:  dataDir,
+		expanded: make(map[*request.Group]bool),
+		List:     list.New([]list.Item{}, list.NewDefaultDelegate(), defaultListWidth-h, defaultListHeight-v),
+		Style:    styles.BorderPanel,
 	}
 
 	m.List.Title = "Requests"
@@ -94,33 +128,78 @@ func New(dataDir string) *Model {
 	return m
 }
 
-// Init sets the content of the viewport to the list of requests.
+// Init loads the requests from the data directory.
 func (m *Model) Init() tea.Cmd {
+	dataDir := m.dataDir
 	return func() tea.Msg {
-		var err error
-		m.Requests, err = request.LoadFrom(m.dataDir)
+		groups, warnings, err := request.LoadFrom(dataDir)
+		if errors.Is(err, os.ErrNotExist) {
+			// having no requests yet is not an error, they are created when saved
+			return LoadedMsg{Groups: make([]*request.Group, 0)}
+		}
 		if err != nil {
 			slog.Error("failed to load requests", slog.Any("error", err))
 			return fmt.Errorf("loading requests from file: %w", err)
 		}
 
-		var items []list.Item
-		for _, group := range m.Requests {
-			if group.Name == request.UnsortedName {
-				items = append(items, group.ListItems()...)
-			} else {
-				items = append(items, group)
-			}
-		}
+		return LoadedMsg{Groups: groups, Warnings: warnings}
+	}
+}
+
+// Refresh updates the tree to reflect the loaded requests.
+func (m *Model) Refresh() tea.Cmd {
+	cmd := m.List.SetItems(tree(m.Requests, nil, 0, m.expanded))
+	m.updateTitle()
+
+	return cmd
+}
+
+// updateTitle shows the group containing the selected item as a breadcrumb.
+func (m *Model) updateTitle() {
+	m.List.Title = "Requests"
+	if item, ok := m.List.SelectedItem().(treeItem); ok && item.parent != nil && item.parent.Name != request.UnsortedName {
+		m.List.Title += " › " + breadcrumb(item.parent)
+	}
+}
+
+// toggle expands or collapses group, keeping it selected.
+func (m *Model) toggle(group *request.Group) tea.Cmd {
+	m.expanded[group] = !m.expanded[group]
+	cmd := m.Refresh()
+	m.selectGroup(group)
 
-		return m.List.SetItems(items)
+	return cmd
+}
+
+// selectGroup moves the cursor to group.
+func (m *Model) selectGroup(group *request.Group) {
+	for i, item := range m.List.Items() {
+		if item.(treeItem).group == group {
+			m.List.Select(i)
+			break
+		}
 	}
+	m.updateTitle()
 }
 
 // Update updates the list and viewport.
 func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 	var commands []tea.Cmd
 	switch msg := msg.(type) {
+	case LoadedMsg:
+		m.Requests = msg.Groups
+		slog.Debug("requests loaded", slog.Int("groups", len(m.Requests)))
+		commands = append(commands, m.Refresh())
+
+		for _, err := range msg.Warnings {
+			slog.Warn("invalid request file", slog.Any("error", err))
+		}
+		if len(msg.Warnings) > 0 {
+			commands = append(commands, notification.Notify(
+				notification.Warn,
+				fmt.Sprintf("%d request files could not be loaded: %v", len(msg.Warnings), errors.Join(msg.Warnings...)),
+			))
+		}
 	case target.FocusMsg:
 		m.Focused = msg.FocusedTarget == target.RequestsTarget && msg.UnfocusedTarget != target.RequestsTarget
 		slog.Debug("updating requests focus", slog.Any("message", msg), slog.Bool("focused", m.Focused))
@@ -137,28 +216,152 @@ func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 		// m.Style.Height(msg.Height - v)
 		m.Style.Width(defaultListWidth - h)
 	case tea.KeyMsg:
-		cmd := m.handleKey(msg)
-		commands = append(commands, cmd)
+		// while filtering, every key edits the filter instead
+		if m.List.FilterState() != list.Filtering {
+			if cmd, ok := m.handleKey(msg); ok {
+				return m, cmd
+			}
+		}
 	}
 
 	reqList, listCmd := m.List.Update(msg)
 	commands = append(commands, listCmd)
 
 	m.List = reqList
+	if _, ok := msg.(tea.KeyMsg); ok {
+		m.updateTitle()
+	}
 	return m, tea.Batch(commands...)
 }
 
-func (m *Model) handleKey(msg tea.KeyMsg) tea.Cmd {
-	var cmd tea.Cmd
+// handleKey handles the keys acting on the selected item, reporting whether msg was one of them. Other keys, such as
+// navigation and filtering, are left to the list.
+func (m *Model) handleKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	item, _ := m.List.SelectedItem().(treeItem)
 
-	switch msg.String() {
-	case "j", tea.KeyDown.String():
-		m.List.CursorDown()
-	case "k", tea.KeyUp.String():
-		m.List.CursorUp()
+	switch {
+	case item.group != nil && key.Matches(msg, selectKey):
+		return m.toggle(item.group), true
+	case item.group != nil && key.Matches(msg, backKey) && m.expanded[item.group]:
+		return m.toggle(item.group), true
+	case key.Matches(msg, backKey) && item.parent != nil && item.parent.Name != request.UnsortedName:
+		// collapse the group containing the selected item
+		return m.toggle(item.parent), true
+	case item.request != nil:
+		r := item.request
+		switch {
+		case key.Matches(msg, selectKey):
+			m.Selected = r
+			slog.Debug("request selected", slog.String("name", r.Name))
+			return func() tea.Msg { return SelectedMsg{Request: r} }, true
+		case key.Matches(msg, renameKey):
+			return func() tea.Msg { return RenameMsg{Request: r} }, true
+		case key.Matches(msg, duplicateKey):
+			return m.Duplicate(r), true
+		case key.Matches(msg, deleteKey):
+			return func() tea.Msg { return DeleteMsg{Request: r} }, true
+		}
 	}
 
-	return cmd
+	return nil, false
+}
+
+// Group returns the group r belongs to, or nil if it hasn't been saved yet.
+func (m *Model) Group(r *request.Request) *request.Group {
+	for _, group := range request.All(m.Requests) {
+		for _, other := range group.Requests {
+			if other == r {
+				return group
+			}
+		}
+	}
+
+	return nil
+}
+
+// unsorted returns the group requests are saved to if they don't belong to one yet, creating it if necessary.
+func (m *Model) unsorted() *request.Group {
+	for _, group := range m.Requests {
+		if group.Name == request.UnsortedName {
+			return group
+		}
+	}
+
+	group := request.NewGroup(request.UnsortedName)
+	group.Path = path.Join(m.dataDir, Dir, request.UnsortedName+".yaml")
+	m.Requests = append(m.Requests, group)
+
+	return group
+}
+
+// Save writes r to the file of its group. A request that doesn't belong to a group yet is added to the unsorted group.
+func (m *Model) Save(r *request.Request) tea.Cmd {
+	var err error
+	if group := m.Group(r); group != nil {
+		err = group.Save()
+	} else {
+		err = m.unsorted().Add(r)
+	}
+
+	return m.done(err, "Saved request "+r.Name)
+}
+
+// SaveAs saves a copy of r named name to the group of r, returning the copy.
+func (m *Model) SaveAs(r *request.Request, name string) (*request.Request, tea.Cmd) {
+	group := m.Group(r)
+	if group == nil {
+		group = m.unsorted()
+	}
+
+	c, err := group.SaveAs(r, name)
+	return c, m.done(err, "Saved request as "+name)
+}
+
+// Rename changes the name of r and saves its group.
+func (m *Model) Rename(r *request.Request, name string) tea.Cmd {
+	group := m.Group(r)
+	if group == nil {
+		r.Name = name
+		return nil
+	}
+
+	old := r.Name
+	return m.done(group.Rename(m.Requests, r, name), fmt.Sprintf("Renamed %s to %s", old, name))
+}
+
+// Duplicate saves a copy of r to its group.
+func (m *Model) Duplicate(r *request.Request) tea.Cmd {
+	group := m.Group(r)
+	if group == nil {
+		return nil
+	}
+
+	c, err := group.Duplicate(r)
+	if err != nil {
+		return m.done(err, "")
+	}
+
+	return m.done(nil, "Duplicated request as "+c.Name)
+}
+
+// Delete removes r from its group and saves it.
+func (m *Model) Delete(r *request.Request) tea.Cmd {
+	group := m.Group(r)
+	if group == nil {
+		return nil
+	}
+
+	return m.done(group.Delete(r), "Deleted request "+r.Name)
+}
+
+// done reports the outcome of changing the requests and refreshes the list.
+func (m *Model) done(err error, message string) tea.Cmd {
+	if err != nil {
+		slog.Error("failed to save requests", slog.Any("error", err))
+		return notification.Notify(notification.Error, "saving requests: "+err.Error())
+	}
+
+	return tea.Batch(notification.Notify(notification.Info, message), m.Refresh())
 }
 
 // View returns the rendering of the viewport.
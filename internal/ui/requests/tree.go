@@ -0,0 +1,82 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+// treeItem is a group or request in the tree of requests, indented by its depth.
+type treeItem struct {
+	group    *request.Group
+	request  *request.Request
+	parent   *request.Group
+	depth    int
+	expanded bool
+}
+
+func (i treeItem) FilterValue() string {
+	if i.group != nil {
+		return i.group.Name
+	}
+
+	return i.request.Name
+}
+
+func (i treeItem) Title() string {
+	indent := strings.Repeat("  ", i.depth)
+	switch {
+	case i.group != nil && i.expanded:
+		return indent + "▾ " + i.group.Title()
+	case i.group != nil:
+		return indent + "▸ " + i.group.Title()
+	default:
+		return indent + i.request.Title()
+	}
+}
+
+func (i treeItem) Description() string {
+	indent := strings.Repeat("  ", i.depth)
+	if i.group != nil {
+		return indent + i.group.Description()
+	}
+
+	return indent + i.request.Description()
+}
+
+// tree lists groups and their requests as a tree, showing the content of expanded groups. The requests of the unsorted
+// group are listed at the top level.
+func tree(groups []*request.Group, parent *request.Group, depth int, expanded map[*request.Group]bool) []list.Item {
+	var items []list.Item
+	for _, group := range groups {
+		if group.Name == request.UnsortedName && parent == nil {
+			for _, r := range group.Requests {
+				items = append(items, treeItem{request: r, parent: group, depth: depth})
+			}
+			continue
+		}
+
+		items = append(items, treeItem{group: group, parent: parent, depth: depth, expanded: expanded[group]})
+		if !expanded[group] {
+			continue
+		}
+
+		items = append(items, tree(group.Groups, group, depth+1, expanded)...)
+		for _, r := range group.Requests {
+			items = append(items, treeItem{request: r, parent: group, depth: depth + 1})
+		}
+	}
+
+	return items
+}
+
+// breadcrumb returns the names of group and the groups it is nested in, e.g. "shop › orders".
+func breadcrumb(group *request.Group) string {
+	if group == nil {
+		return ""
+	}
+
+	return strings.ReplaceAll(group.FullName(), "/", " › ")
+}
@@ -0,0 +1,56 @@
+package response
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cstaaben/go-rest/internal/client"
+	"github.com/cstaaben/go-rest/internal/request"
+)
+
+// ResultMsg is sent when a request sent with Send has completed.
+type ResultMsg struct {
+	// ID identifies which call to Send produced the result.
+	ID       int
+	Request  *request.Request
+	Response *request.Response
+	// Previous is the response the request had before it was sent, if any.
+	Previous *request.Response
+	Err      error
+}
+
+// Send sends data on behalf of r with c in the background. data should be a copy of the data of r, so later changes to
+// r do not affect the request in flight. previous is the response of the last run of r to compare with, if any.
+func Send(
+	ctx context.Context, c *client.Client, id int, r *request.Request, data *request.Data,
+	previous *request.Response,
+) tea.Cmd {
+	sent := &request.Request{
+		Name: r.Name,
+		Desc: r.Desc,
+		Data: data,
+	}
+
+	return func() tea.Msg {
+		resp, err := c.Do(ctx, sent)
+		return ResultMsg{
+			ID:       id,
+			Request:  r,
+			Response: resp,
+			Previous: previous,
+			Err:      err,
+		}
+	}
+}
+
+// Fail reports that r with the given ID could not be sent because of err.
+func Fail(id int, r *request.Request, err error) tea.Cmd {
+	return func() tea.Msg {
+		return ResultMsg{
+			ID:      id,
+			Request: r,
+			Err:     err,
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package response
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cstaaben/go-rest/internal/ui/styles"
+)
+
+type bodyKind int
+
+const (
+	textBody bodyKind = iota
+	binaryBody
+	jsonBody
+	xmlBody
+	htmlBody
+	yamlBody
+)
+
+const indent = "  "
+
+var markupEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// highlighter holds the styles used to color each kind of token.
+type highlighter struct {
+	key, str, num, lit, punct, comment lipgloss.Style
+}
+
+func newHighlighter() *highlighter {
+	c := styles.Colors()
+	return &highlighter{
+		key:     lipgloss.NewStyle().Foreground(c.Key),
+		str:     lipgloss.NewStyle().Foreground(c.String),
+		num:     lipgloss.NewStyle().Foreground(c.Number),
+		lit:     lipgloss.NewStyle().Foreground(c.Literal),
+		punct:   lipgloss.NewStyle().Foreground(c.Punctuation),
+		comment: lipgloss.NewStyle().Foreground(c.Comment),
+	}
+}
+
+// Format pretty-prints and highlights body according to contentType. If the body can't be parsed as the type it claims
+// to be, it is returned as-is. Binary bodies are rendered as a hex dump.
+func Format(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	h := newHighlighter()
+
+	switch kindOf(contentType, body) {
+	case binaryBody:
+		return hex.Dump(body)
+	case jsonBody:
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, body, "", indent); err == nil {
+			return h.json(buf.Bytes())
+		}
+	case xmlBody:
+		if s, err := h.markup(body, false); err == nil {
+			return s
+		}
+	case htmlBody:
+		if s, err := h.markup(body, true); err == nil {
+			return s
+		}
+	case yamlBody:
+		return h.yaml(Sanitize(string(body)))
+	}
+
+	return Sanitize(string(body))
+}
+
+func kindOf(contentType string, body []byte) bodyKind {
+	if isBinary(body) {
+		return binaryBody
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "" || mediaType == "text/plain" || mediaType == "application/octet-stream" {
+		// the server didn't say what the body is, so try to figure it out
+		if json.Valid(body) {
+			return jsonBody
+		}
+
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+
+	switch {
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+		return jsonBody
+	case mediaType == "text/html", mediaType == "application/xhtml+xml":
+		return htmlBody
+	case mediaType == "application/xml", mediaType == "text/xml", strings.HasSuffix(mediaType, "+xml"):
+		return xmlBody
+	case strings.HasSuffix(mediaType, "yaml"):
+		return yamlBody
+	}
+
+	return textBody
+}
+
+// isBinary reports whether body contains anything other than printable UTF-8 text. Control characters other than
+// whitespace make a body binary, since escape sequences written to the terminal could rewrite the screen or the
+// clipboard.
+func isBinary(body []byte) bool {
+	if !utf8.Valid(body) {
+		return true
+	}
+
+	for _, r := range string(body) {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' && r != '\f' {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sanitize escapes the C0 and C1 control characters in s other than tabs and line breaks, so content controlled by the
+// server can't send escape sequences to the terminal. Line breaks are normalized to "\n".
+func Sanitize(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if !strings.ContainsFunc(s, func(r rune) bool { return unicode.IsControl(r) && r != '\t' && r != '\n' }) {
+		return s
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for _, r := range s {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' {
+			fmt.Fprintf(&sb, "\\x%02x", r)
+			continue
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+// json highlights src, which must already be indented JSON.
+func (h *highlighter) json(src []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(src))
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(src))
+
+			// a string followed by a colon is an object key
+			next := end
+			for next < len(src) && src[next] == ' ' {
+				next++
+			}
+
+			if next < len(src) && src[next] == ':' {
+				sb.WriteString(h.key.Render(string(src[i:end])))
+			} else {
+				sb.WriteString(h.str.Render(string(src[i:end])))
+			}
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(src) && strings.IndexByte("+-.eE0123456789", src[end]) >= 0 {
+				end++
+			}
+			sb.WriteString(h.num.Render(string(src[i:end])))
+			i = end
+		case c >= 'a' && c <= 'z':
+			end := i + 1
+			for end < len(src) && src[end] >= 'a' && src[end] <= 'z' {
+				end++
+			}
+			sb.WriteString(h.lit.Render(string(src[i:end])))
+			i = end
+		case strings.IndexByte("{}[]:,", c) >= 0:
+			sb.WriteString(h.punct.Render(string(c)))
+			i++
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+
+	return sb.String()
+}
+
+// markup re-indents and highlights an XML document. If html is set, the parser is lenient about unclosed elements and
+// HTML entities.
+func (h *highlighter) markup(body []byte, html bool) (string, error) {
+	d := xml.NewDecoder(bytes.NewReader(body))
+	next := d.RawToken
+	if html {
+		d.Strict = false
+		d.AutoClose = xml.HTMLAutoClose
+		d.Entity = xml.HTMLEntity
+		// auto-closing elements is only done by Token
+		next = d.Token
+	}
+
+	var tokens []xml.Token
+	for {
+		tok, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return "", err
+		}
+
+		tokens = append(tokens, xml.CopyToken(tok))
+	}
+
+	var (
+		sb    strings.Builder
+		depth int
+	)
+
+	for i := 0; i < len(tokens); i++ {
+		prefix := strings.Repeat(indent, depth)
+
+		switch t := tokens[i].(type) {
+		case xml.StartElement:
+			sb.WriteString(prefix + h.startTag(t))
+
+			// collapse empty elements and elements with only text into a single line
+			if _, ok := tokenAt[xml.EndElement](tokens, i+1); ok {
+				sb.WriteString(h.punct.Render("/>") + "\n")
+				i++
+				continue
+			}
+
+			if text, ok := tokenAt[xml.CharData](tokens, i+1); ok {
+				if end, ok := tokenAt[xml.EndElement](tokens, i+2); ok && !strings.Contains(string(text), "\n") {
+					sb.WriteString(h.punct.Render(">") + markupEscaper.Replace(string(text)) + h.endTag(end) + "\n")
+					i += 2
+					continue
+				}
+			}
+
+			sb.WriteString(h.punct.Render(">") + "\n")
+			depth++
+		case xml.EndElement:
+			depth = max(depth-1, 0)
+			sb.WriteString(strings.Repeat(indent, depth) + h.endTag(t) + "\n")
+		case xml.CharData:
+			text := strings.TrimSpace(string(t))
+			if text == "" {
+				continue
+			}
+
+			for _, line := range strings.Split(text, "\n") {
+				sb.WriteString(prefix + markupEscaper.Replace(strings.TrimSpace(line)) + "\n")
+			}
+		case xml.Comment:
+			sb.WriteString(prefix + h.comment.Render("<!--"+string(t)+"-->") + "\n")
+		case xml.ProcInst:
+			sb.WriteString(prefix + h.comment.Render("<?"+t.Target+" "+string(t.Inst)+"?>") + "\n")
+		case xml.Directive:
+			sb.WriteString(prefix + h.comment.Render("<!"+string(t)+">") + "\n")
+		}
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// tokenAt returns the token at index i if it is a T. Whitespace-only character data is never matched.
+func tokenAt[T xml.Token](tokens []xml.Token, i int) (T, bool) {
+	var zero T
+	if i >= len(tokens) {
+		return zero, false
+	}
+
+	t, ok := tokens[i].(T)
+	if text, isText := tokens[i].(xml.CharData); isText && strings.TrimSpace(string(text)) == "" {
+		return zero, false
+	}
+
+	return t, ok
+}
+
+func (h *highlighter) startTag(t xml.StartElement) string {
+	var sb strings.Builder
+	sb.WriteString(h.punct.Render("<") + h.key.Render(markupName(t.Name)))
+
+	for _, attr := range t.Attr {
+		value := strings.ReplaceAll(markupEscaper.Replace(attr.Value), `"`, "&quot;")
+		sb.WriteString(" " + h.lit.Render(markupName(attr.Name)) + h.punct.Render("=") + h.str.Render(`"`+value+`"`))
+	}
+
+	return sb.String()
+}
+
+func (h *highlighter) endTag(t xml.EndElement) string {
+	return h.punct.Render("</") + h.key.Render(markupName(t.Name)) + h.punct.Render(">")
+}
+
+func markupName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
+
+// yaml highlights src line by line. YAML is already meant to be read by people, so it is not re-indented.
+func (h *highlighter) yaml(src string) string {
+	lines := strings.Split(src, "\n")
+
+	for i, line := range lines {
+		content := strings.TrimLeft(line, " ")
+		var sb strings.Builder
+		sb.WriteString(line[:len(line)-len(content)])
+
+		switch {
+		case strings.HasPrefix(content, "#"):
+			sb.WriteString(h.comment.Render(content))
+			lines[i] = sb.String()
+			continue
+		case content == "---" || content == "...":
+			sb.WriteString(h.punct.Render(content))
+			lines[i] = sb.String()
+			continue
+		}
+
+		for strings.HasPrefix(content, "- ") || content == "-" {
+			sb.WriteString(h.punct.Render("-"))
+			content = strings.TrimPrefix(strings.TrimPrefix(content, "-"), " ")
+			sb.WriteString(" ")
+		}
+
+		if k, v, ok := splitYAMLKey(content); ok {
+			sb.WriteString(h.key.Render(k) + h.punct.Render(":"))
+			content = v
+		}
+
+		sb.WriteString(h.yamlValue(content))
+		lines[i] = strings.TrimSuffix(sb.String(), " ")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// splitYAMLKey splits "key: value" into its key and the remainder of the line after the colon.
+func splitYAMLKey(s string) (string, string, bool) {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'") {
+		end := strings.IndexByte(s[1:], s[0])
+		if end < 0 || !strings.HasPrefix(s[end+2:], ":") {
+			return "", "", false
+		}
+
+		return s[:end+2], s[end+3:], true
+	}
+
+	idx := strings.Index(s, ": ")
+	if idx < 0 {
+		if !strings.HasSuffix(s, ":") {
+			return "", "", false
+		}
+		idx = len(s) - 1
+	}
+
+	if strings.ContainsAny(s[:idx], "#{[") {
+		return "", "", false
+	}
+
+	return s[:idx], s[idx+1:], true
+}
+
+func (h *highlighter) yamlValue(s string) string {
+	value := strings.TrimLeft(s, " ")
+	lead := s[:len(s)-len(value)]
+
+	var comment string
+	if idx := strings.Index(value, " #"); idx >= 0 && !strings.HasPrefix(value, `"`) && !strings.HasPrefix(value, "'") {
+		value, comment = value[:idx], h.comment.Render(value[idx:])
+	}
+
+	switch {
+	case value == "":
+		return lead + comment
+	case value == "true", value == "false", value == "null", value == "~":
+		return lead + h.lit.Render(value) + comment
+	case json.Valid([]byte(value)) && (value[0] == '-' || (value[0] >= '0' && value[0] <= '9')):
+		return lead + h.num.Render(value) + comment
+	case value == "|", value == ">", value == "|-", value == ">-":
+		return lead + h.punct.Render(value) + comment
+	default:
+		return lead + h.str.Render(value) + comment
+	}
+}
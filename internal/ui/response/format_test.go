@@ -0,0 +1,140 @@
+package response_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cstaaben/go-rest/internal/ui/response"
+)
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{
+			name:        "Empty body",
+			contentType: "application/json",
+			body:        "",
+			expected:    "",
+		},
+		{
+			name:        "JSON",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"a":1,"b":[true,null],"c":"x: y"}`,
+			expected:    "{\n  \"a\": 1,\n  \"b\": [\n    true,\n    null\n  ],\n  \"c\": \"x: y\"\n}",
+		},
+		{
+			name:        "JSON suffix",
+			contentType: "application/problem+json",
+			body:        `{"title":"Not Found"}`,
+			expected:    "{\n  \"title\": \"Not Found\"\n}",
+		},
+		{
+			name:        "Sniffed JSON",
+			contentType: "",
+			body:        `[1,2]`,
+			expected:    "[\n  1,\n  2\n]",
+		},
+		{
+			name:        "Invalid JSON",
+			contentType: "application/json",
+			body:        `{"a":`,
+			expected:    `{"a":`,
+		},
+		{
+			name:        "XML",
+			contentType: "application/xml",
+			body:        `<?xml version="1.0"?><root a="1"><empty/><child>text &amp; more</child><!-- note --></root>`,
+			expected: "<?xml version=\"1.0\"?>\n" +
+				"<root a=\"1\">\n" +
+				"  <empty/>\n" +
+				"  <child>text &amp; more</child>\n" +
+				"  <!-- note -->\n" +
+				"</root>",
+		},
+		{
+			name:        "HTML",
+			contentType: "text/html",
+			body:        `<!DOCTYPE html><html><body><p>Hello<br></p></body></html>`,
+			expected: "<!DOCTYPE html>\n" +
+				"<html>\n" +
+				"  <body>\n" +
+				"    <p>\n" +
+				"      Hello\n" +
+				"      <br/>\n" +
+				"    </p>\n" +
+				"  </body>\n" +
+				"</html>",
+		},
+		{
+			name:        "YAML",
+			contentType: "application/yaml",
+			body:        "# comment\nkey: value\nlist:\n  - 1\n  - name: x # trailing\n",
+			expected:    "# comment\nkey: value\nlist:\n  - 1\n  - name: x # trailing\n",
+		},
+		{
+			name:        "Plain text",
+			contentType: "text/plain",
+			body:        "hello, world",
+			expected:    "hello, world",
+		},
+		{
+			name:        "Binary",
+			contentType: "application/octet-stream",
+			body:        "\x00\x01\x02go-rest",
+			expected:    "00000000  00 01 02 67 6f 2d 72 65  73 74                    |...go-rest|\n",
+		},
+		{
+			name:        "Escape sequences",
+			contentType: "text/plain",
+			body:        "\x1b]52;c;aGVsbG8=\x07",
+			expected:    "00000000  1b 5d 35 32 3b 63 3b 61  47 56 73 62 47 38 3d 07  |.]52;c;aGVsbG8=.|\n",
+		},
+		{
+			name:        "C1 control characters",
+			contentType: "text/plain",
+			body:        "\u009b2J",
+			expected:    "00000000  c2 9b 32 4a                                       |..2J|\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				actual := response.Format(tc.contentType, []byte(tc.body))
+				assert.Equal(t, tc.expected, actual)
+			},
+		)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "Plain", input: "text/plain; charset=utf-8", expected: "text/plain; charset=utf-8"},
+		{name: "Whitespace", input: "a\tb\r\nc\n", expected: "a\tb\nc\n"},
+		{name: "Clipboard write", input: "v\x1b]52;c;aGVsbG8=\x07", expected: `v\x1b]52;c;aGVsbG8=\x07`},
+		{name: "Window title", input: "\x1b]0;pwned\x1b\\", expected: `\x1b]0;pwned\x1b\`},
+		{name: "C1 control", input: "\u009b2J", expected: `\x9b2J`},
+		{name: "Lone carriage return", input: "ok\rfail", expected: `ok\x0dfail`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(
+			tc.name, func(t *testing.T) {
+				assert.Equal(t, tc.expected, response.Sanitize(tc.input))
+			},
+		)
+	}
+}
@@ -19,43 +19,172 @@
 package response
 
 import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"log/slog"
+
+	"github.com/cstaaben/go-rest/internal/model/keymap"
+	"github.com/cstaaben/go-rest/internal/model/target"
+	"github.com/cstaaben/go-rest/internal/request"
+	"github.com/cstaaben/go-rest/internal/ui/styles"
+)
+
+const (
+	defaultWidth  = 400
+	defaultHeight = 200
+	// listWidth is the width taken up by the requests pane.
+	listWidth = 50
 )
 
 type Model struct {
 	// ui
-	Spinner  spinner.Model
-	Viewport viewport.Model
-	Focused  bool
+	Spinner spinner.Model
+	// Viewports contains a viewport for each tab, so each keeps its own scroll position.
+	Viewports []viewport.Model
+	ActiveTab Tab
+	Focused   bool
+	Style     lipgloss.Style
+	Keys      *keymap.KeyMap
 	// data
-	Response []byte
+	Response *request.Response
+	// Previous is the response received the last time the displayed request was sent, used for comparison.
+	Previous *request.Response
 	Error    error
+	// Active is the ID of the request whose result is displayed.
+	Active int
+	// Pending contains every request that has been sent but has not completed, keyed by ID.
+	Pending map[int]*request.Request
 }
 
 func New() *Model {
-	return &Model{
-		Spinner:  spinner.New(spinner.WithSpinner(spinner.Meter)),
-		Viewport: viewport.New(400, 200),
+	m := &Model{
+		Spinner:   spinner.New(spinner.WithSpinner(spinner.Meter)),
+		Viewports: make([]viewport.Model, tabCount),
+		Style:     styles.BorderPanel,
+		Keys:      keymap.Default,
+		Pending:   make(map[int]*request.Request),
+	}
+
+	for i := range m.Viewports {
+		m.Viewports[i] = viewport.New(defaultWidth, defaultHeight)
 	}
+
+	return m
 }
 
 // Init is the first function that will be called. It returns an optional
 // initial command. To not perform an initial command return nil.
 func (model *Model) Init() tea.Cmd {
-	panic("not implemented") // TODO: Implement
+	return nil
+}
+
+// Sending marks r as in flight with the given ID and displays it as the active request.
+func (model *Model) Sending(id int, r *request.Request) tea.Cmd {
+	model.Pending[id] = r
+	model.Active = id
+	model.Response = nil
+	model.Previous = nil
+	model.Error = nil
+	model.setContent()
+
+	return model.Spinner.Tick
 }
 
 // Update is called when a message is received. Use it to inspect messages
 // and, in response, update the model and/or send a command.
-func (model *Model) Update(_ tea.Msg) (*Model, tea.Cmd) {
-	// TODO: Implement
-	return model, nil
+func (model *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	var commands []tea.Cmd
+
+	switch msg := msg.(type) {
+	case ResultMsg:
+		delete(model.Pending, msg.ID)
+		slog.Debug("request completed", slog.Int("id", msg.ID), slog.Int("active", model.Active), slog.Any("error", msg.Err))
+
+		// results of requests sent before the active one are stored on their request, but not displayed
+		if msg.ID == model.Active {
+			model.Response = msg.Response
+			model.Previous = msg.Previous
+			model.Error = msg.Err
+			model.setContent()
+		}
+	case spinner.TickMsg:
+		if _, ok := model.Pending[model.Active]; ok {
+			var cmd tea.Cmd
+			model.Spinner, cmd = model.Spinner.Update(msg)
+			commands = append(commands, cmd)
+		}
+	case tea.WindowSizeMsg:
+		h, v := model.Style.GetFrameSize()
+		for i := range model.Viewports {
+			// leave room for the status line and tab bar
+			model.Viewports[i].Width = msg.Width - listWidth - h
+			model.Viewports[i].Height = msg.Height/2 - v - 2
+		}
+	case target.FocusMsg:
+		model.Focused = msg.FocusedTarget == target.ResponseTarget && msg.UnfocusedTarget != target.ResponseTarget
+		if model.Focused {
+			model.Style = styles.FocusedBorder
+		} else {
+			model.Style = styles.BorderPanel
+		}
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, model.Keys.NextTab):
+			model.ActiveTab = model.ActiveTab.Next()
+		case key.Matches(msg, model.Keys.PreviousTab):
+			model.ActiveTab = model.ActiveTab.Prev()
+		default:
+			var cmd tea.Cmd
+			model.Viewports[model.ActiveTab], cmd = model.Viewports[model.ActiveTab].Update(msg)
+			commands = append(commands, cmd)
+		}
+	default:
+		var cmd tea.Cmd
+		model.Viewports[model.ActiveTab], cmd = model.Viewports[model.ActiveTab].Update(msg)
+		commands = append(commands, cmd)
+	}
+
+	return model, tea.Batch(commands...)
 }
 
 // View renders the program's UI, which is just a string. The view is
 // rendered after every Update.
 func (model *Model) View() string {
-	panic("not implemented") // TODO: Implement
+	var status string
+	switch {
+	case model.Pending[model.Active] != nil:
+		status = model.Spinner.View() + " Sending " + model.Pending[model.Active].Name
+	case model.Error != nil:
+		status = "Error: " + model.Error.Error()
+	case model.Response != nil:
+		status = fmt.Sprintf("%s %s (%s)", model.Response.Proto, Sanitize(model.Response.Status), model.Response.Timing.Total)
+		if n := len(model.Response.Redirects); n == 1 {
+			status += " after 1 redirect"
+		} else if n > 1 {
+			status += fmt.Sprintf(" after %d redirects", n)
+		}
+	default:
+		status = "No response"
+	}
+
+	return model.Style.Render(lipgloss.JoinVertical(
+		lipgloss.Left,
+		styles.Title.Render(status),
+		renderTabs(model.ActiveTab),
+		model.Viewports[model.ActiveTab].View(),
+	))
+}
+
+// setContent renders every tab for the current response. Content is only rendered when the response changes, so
+// scrolling doesn't have to format the response again.
+func (model *Model) setContent() {
+	for i := range model.Viewports {
+		model.Viewports[i].SetContent(render(Tab(i), model.Response, model.Previous))
+		model.Viewports[i].GotoTop()
+	}
 }
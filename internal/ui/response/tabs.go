@@ -0,0 +1,306 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cstaaben/go-rest/internal/request"
+	"github.com/cstaaben/go-rest/internal/secrets"
+	"github.com/cstaaben/go-rest/internal/ui/styles"
+)
+
+// Tab is one of the views of a response.
+type Tab int
+
+const (
+	BodyTab Tab = iota
+	HeadersTab
+	CookiesTab
+	TimingTab
+	RawTab
+
+	tabCount = int(RawTab) + 1
+)
+
+// waterfallWidth is the width of the bars in the timing tab.
+const waterfallWidth = 40
+
+func (t Tab) String() string {
+	switch t {
+	case BodyTab:
+		return "Body"
+	case HeadersTab:
+		return "Headers"
+	case CookiesTab:
+		return "Cookies"
+	case TimingTab:
+		return "Timing"
+	case RawTab:
+		return "Raw"
+	default:
+		return ""
+	}
+}
+
+// Next returns the tab after t, wrapping around to the first tab.
+func (t Tab) Next() Tab {
+	return Tab((int(t) + 1) % tabCount)
+}
+
+// Prev returns the tab before t, wrapping around to the last tab.
+func (t Tab) Prev() Tab {
+	return Tab((int(t) + tabCount - 1) % tabCount)
+}
+
+// renderTabs renders the tab bar with active highlighted.
+func renderTabs(active Tab) string {
+	tabs := make([]string, 0, tabCount)
+	for t := Tab(0); int(t) < tabCount; t++ {
+		if t == active {
+			tabs = append(tabs, styles.ActiveTab.Render(t.String()))
+		} else {
+			tabs = append(tabs, styles.Tab.Render(t.String()))
+		}
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+}
+
+// render returns the content of tab t for resp with any secret values masked. If previous is set, the timing of resp
+// is compared to it.
+func render(t Tab, resp, previous *request.Response) string {
+	return secrets.Mask(renderTab(t, resp, previous))
+}
+
+func renderTab(t Tab, resp, previous *request.Response) string {
+	if resp == nil {
+		return ""
+	}
+
+	switch t {
+	case BodyTab:
+		return Format(resp.ContentType(), resp.Body)
+	case HeadersTab:
+		return renderHeaders(resp.Headers)
+	case CookiesTab:
+		return renderCookies(resp.Cookies())
+	case TimingTab:
+		var prev *request.Timing
+		if previous != nil {
+			prev = &previous.Timing
+		}
+
+		timing := renderTiming(resp.Timing, prev)
+		if len(resp.Redirects) > 0 {
+			timing = renderRedirects(resp.Redirects) + "\n\n" + timing
+		}
+		if resp.TLS != nil {
+			timing += "\n\n" + renderTLS(resp.TLS)
+		}
+
+		return timing
+	case RawTab:
+		return renderRaw(resp)
+	default:
+		return ""
+	}
+}
+
+func renderHeaders(headers map[string][]string) string {
+	h := newHighlighter()
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		for _, value := range headers[name] {
+			sb.WriteString(h.key.Render(Sanitize(name)) + h.punct.Render(":") + " " + Sanitize(value) + "\n")
+		}
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func renderCookies(cookies []*http.Cookie) string {
+	if len(cookies) == 0 {
+		return "No cookies"
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tValue\tDomain\tPath\tExpires\tFlags") // nolint:errcheck
+
+	for _, c := range cookies {
+		expires := "session"
+		switch {
+		case c.MaxAge < 0:
+			expires = "expired"
+		case c.MaxAge > 0:
+			expires = fmt.Sprintf("max-age=%d", c.MaxAge)
+		case !c.Expires.IsZero():
+			expires = c.Expires.Format(http.TimeFormat)
+		}
+
+		var flags []string
+		if c.Secure {
+			flags = append(flags, "Secure")
+		}
+		if c.HttpOnly {
+			flags = append(flags, "HttpOnly")
+		}
+		switch c.SameSite {
+		case http.SameSiteLaxMode:
+			flags = append(flags, "SameSite=Lax")
+		case http.SameSiteStrictMode:
+			flags = append(flags, "SameSite=Strict")
+		case http.SameSiteNoneMode:
+			flags = append(flags, "SameSite=None")
+		}
+
+		fmt.Fprintf( // nolint:errcheck
+			w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			Sanitize(c.Name), Sanitize(c.Value), Sanitize(c.Domain), Sanitize(c.Path), expires, strings.Join(flags, " "),
+		)
+	}
+
+	w.Flush() // nolint:errcheck
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// renderTiming renders each phase of timing as a bar in a waterfall chart, so both how long a phase took and when it
+// started are visible.
+func renderTiming(timing request.Timing, previous *request.Timing) string {
+	h := newHighlighter()
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Started: %s\n", timing.Start.Format("2006-01-02 15:04:05.000")))
+	if timing.Reused {
+		sb.WriteString(h.comment.Render("connection reused") + "\n")
+	}
+	sb.WriteString("\n")
+
+	var prevPhases []request.Phase
+	if previous != nil {
+		prevPhases = previous.Phases()
+	}
+
+	var offset time.Duration
+	for i, phase := range timing.Phases() {
+		sb.WriteString(fmt.Sprintf("%-8s %s %10s", phase.Name, waterfallBar(offset, phase.Duration, timing.Total), phase.Duration))
+		if prevPhases != nil {
+			sb.WriteString(h.comment.Render(fmt.Sprintf("  %s", compare(phase.Duration, prevPhases[i].Duration))))
+		}
+		sb.WriteString("\n")
+
+		offset += phase.Duration
+	}
+
+	sb.WriteString(fmt.Sprintf("%-8s %s %10s", "Total", strings.Repeat(" ", waterfallWidth), timing.Total))
+	if previous != nil {
+		sb.WriteString(h.comment.Render(fmt.Sprintf("  %s", compare(timing.Total, previous.Total))))
+	}
+
+	return sb.String()
+}
+
+// renderRedirects renders each redirect followed to get a response, with where it pointed to and how long it took.
+func renderRedirects(hops []request.Hop) string {
+	h := newHighlighter()
+
+	var sb strings.Builder
+	sb.WriteString(h.key.Render("Redirects") + h.punct.Render(":") + "\n")
+
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	for i, hop := range hops {
+		fmt.Fprintf( // nolint:errcheck
+			w, "%d\t%s\t%s %s\t→ %s\t%s\n",
+			i+1, Sanitize(hop.Status), hop.Method, Sanitize(hop.URL), Sanitize(hop.Location), hop.Timing.Total,
+		)
+	}
+	w.Flush() // nolint:errcheck
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// renderTLS renders the negotiated parameters of a TLS connection and the certificate chain of the server.
+func renderTLS(info *request.TLSInfo) string {
+	h := newHighlighter()
+
+	var sb strings.Builder
+	sb.WriteString(h.key.Render("TLS") + h.punct.Render(":") + " " + info.Version + ", " + info.CipherSuite + "\n")
+	if info.Protocol != "" {
+		sb.WriteString(h.key.Render("ALPN") + h.punct.Render(":") + " " + info.Protocol + "\n")
+	}
+	if info.ServerName != "" {
+		sb.WriteString(h.key.Render("Server name") + h.punct.Render(":") + " " + Sanitize(info.ServerName) + "\n")
+	}
+
+	for i, cert := range info.Certificates {
+		sb.WriteString(fmt.Sprintf("\n%d %s\n", i, Sanitize(cert.Subject)))
+		sb.WriteString(h.comment.Render("  issued by "+Sanitize(cert.Issuer)) + "\n")
+		if len(cert.DNSNames) > 0 {
+			sb.WriteString(h.comment.Render("  names "+Sanitize(strings.Join(cert.DNSNames, ", "))) + "\n")
+		}
+		sb.WriteString(h.comment.Render(fmt.Sprintf(
+			"  valid %s to %s",
+			cert.NotBefore.Format(time.DateOnly),
+			cert.NotAfter.Format(time.DateOnly),
+		)) + "\n")
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// waterfallBar draws a bar for a phase that starts at offset and lasts for d, scaled relative to total.
+func waterfallBar(offset, d, total time.Duration) string {
+	if total <= 0 {
+		return strings.Repeat(" ", waterfallWidth)
+	}
+
+	start := min(int(int64(waterfallWidth)*int64(offset)/int64(total)), waterfallWidth)
+	length := int(int64(waterfallWidth) * int64(d) / int64(total))
+	if d > 0 && length == 0 {
+		// always show that a phase happened, however short
+		length = 1
+	}
+	length = min(length, waterfallWidth-start)
+
+	bar := lipgloss.NewStyle().Foreground(styles.Colors().FocusHighlight).Render(strings.Repeat("█", length))
+	return strings.Repeat(" ", start) + bar + strings.Repeat(" ", waterfallWidth-start-length)
+}
+
+// compare describes the difference between d and the previous duration.
+func compare(d, previous time.Duration) string {
+	diff := d - previous
+	if diff >= 0 {
+		return fmt.Sprintf("(was %s, +%s)", previous, diff)
+	}
+
+	return fmt.Sprintf("(was %s, -%s)", previous, -diff)
+}
+
+func renderRaw(resp *request.Response) string {
+	body := string(resp.Body)
+	if isBinary(resp.Body) {
+		body = fmt.Sprintf("<%d bytes of binary data>", len(resp.Body))
+	}
+
+	// every part but the request sent is controlled by the server, and the request may echo values it returned
+	return Sanitize(strings.Join([]string{
+		strings.TrimRight(resp.RawRequest, "\r\n"),
+		"",
+		strings.TrimRight(resp.RawHeaders, "\r\n"),
+		"",
+		body,
+	}, "\n"))
+}
@@ -11,6 +11,9 @@ var (
 	BorderPanel   = lipgloss.NewStyle().Inherit(Base).Border(lipgloss.RoundedBorder(), true)
 	FocusedBorder = lipgloss.NewStyle().Inherit(BorderPanel).BorderForeground(Colors().FocusHighlight)
 	Title         = lipgloss.NewStyle().Padding(0, 1).Bold(true).Align(lipgloss.Center).Inherit(Base)
+	Tab           = lipgloss.NewStyle().Padding(0, 1).Foreground(Colors().Foreground)
+	ActiveTab     = lipgloss.NewStyle().Inherit(Tab).Bold(true).Underline(true).Foreground(Colors().FocusHighlight)
+	ErrorText     = lipgloss.NewStyle().Foreground(Colors().Error)
 
 	colors        *ColorScheme
 	defaultColors = ColorScheme{
@@ -22,12 +25,57 @@ var (
 			Light: "#000000",
 			Dark:  "#ffffff",
 		},
+		Key: lipgloss.AdaptiveColor{
+			Light: "#005fd7",
+			Dark:  "#5fafff",
+		},
+		String: lipgloss.AdaptiveColor{
+			Light: "#008700",
+			Dark:  "#87d787",
+		},
+		Number: lipgloss.AdaptiveColor{
+			Light: "#af5f00",
+			Dark:  "#ffaf5f",
+		},
+		Literal: lipgloss.AdaptiveColor{
+			Light: "#af00af",
+			Dark:  "#d787ff",
+		},
+		Punctuation: lipgloss.AdaptiveColor{
+			Light: "#585858",
+			Dark:  "#a8a8a8",
+		},
+		Comment: lipgloss.AdaptiveColor{
+			Light: "#8a8a8a",
+			Dark:  "#6c6c6c",
+		},
+		Error: lipgloss.AdaptiveColor{
+			Light: "#d70000",
+			Dark:  "#ff5f5f",
+		},
 	}
 )
 
 type ColorScheme struct {
 	FocusHighlight lipgloss.AdaptiveColor
 	Foreground     lipgloss.AdaptiveColor
+	// Error is used for errors and warnings.
+	Error lipgloss.AdaptiveColor
+
+	// syntax highlighting
+
+	// Key is used for object keys, tag names and attribute names.
+	Key lipgloss.AdaptiveColor
+	// String is used for string values.
+	String lipgloss.AdaptiveColor
+	// Number is used for numeric values.
+	Number lipgloss.AdaptiveColor
+	// Literal is used for booleans, null and similar keywords.
+	Literal lipgloss.AdaptiveColor
+	// Punctuation is used for brackets, separators and other structural characters.
+	Punctuation lipgloss.AdaptiveColor
+	// Comment is used for comments, directives and processing instructions.
+	Comment lipgloss.AdaptiveColor
 }
 
 func Colors() *ColorScheme {